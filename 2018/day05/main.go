@@ -2,11 +2,16 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 func readInput(filename string) (string, error) {
@@ -35,60 +40,157 @@ func readInput(filename string) (string, error) {
 	return text, nil
 }
 
-func react(polymer string) string {
-	for {
-		var result strings.Builder
+// ReactionRule decides which pairs of adjacent units destroy each other and
+// which units belong to the same type.
+type ReactionRule interface {
+	Reacts(x, y rune) bool
+	UnitType(x rune) rune
+}
 
-		for i := 0; i < len(polymer); {
-			current := polymer[i]
+// CaseRule is the puzzle's rule: units of the same type and opposite polarity
+// react, where type is the letter and polarity is its case. It works for any
+// Unicode letters with case pairs, not just ASCII.
+type CaseRule struct{}
 
-			if i+1 < len(polymer) {
-				next := polymer[i+1]
-				if areReactive(current, next) {
-					i += 2
-					continue
-				}
-			}
+func (CaseRule) Reacts(x, y rune) bool {
+	if x == y || unicode.IsUpper(x) == unicode.IsUpper(y) {
+		return false
+	}
+	return unicode.ToLower(x) == unicode.ToLower(y)
+}
 
-			result.WriteByte(current)
-			i++
+func (CaseRule) UnitType(x rune) rune {
+	return unicode.ToLower(x)
+}
+
+// PairRule reacts arbitrary pairs of units. Each pair is symmetric: a unit
+// reacts with its partner regardless of which comes first.
+type PairRule struct {
+	partners map[rune]rune
+}
+
+func NewPairRule(pairs ...[2]rune) (PairRule, error) {
+	partners := make(map[rune]rune)
+	for _, pair := range pairs {
+		x, y := pair[0], pair[1]
+		if x == y {
+			return PairRule{}, fmt.Errorf("unit %q cannot react with itself", x)
+		}
+		for _, unit := range []rune{x, y} {
+			if _, ok := partners[unit]; ok {
+				return PairRule{}, fmt.Errorf("unit %q appears in more than one pair", unit)
+			}
 		}
+		partners[x] = y
+		partners[y] = x
+	}
+	return PairRule{partners: partners}, nil
+}
 
-		newPolymer := result.String()
-		if newPolymer == polymer {
-			return polymer
+// parsePairRule reads pairs written as two-unit strings separated by commas,
+// e.g. "aA,bB,+-".
+func parsePairRule(spec string) (PairRule, error) {
+	pairs := make([][2]rune, 0)
+	for _, raw := range strings.Split(spec, ",") {
+		units := []rune(strings.TrimSpace(raw))
+		if len(units) != 2 {
+			return PairRule{}, fmt.Errorf("pair \"%s\" must have exactly 2 units", raw)
 		}
-		polymer = newPolymer
+		pairs = append(pairs, [2]rune{units[0], units[1]})
 	}
+	return NewPairRule(pairs...)
 }
 
-func areReactive(x, y byte) bool {
-	return abs16(int16(x)-int16(y)) == 32
+func (r PairRule) Reacts(x, y rune) bool {
+	partner, ok := r.partners[x]
+	return ok && partner == y
 }
 
-func abs16(x int16) int16 {
-	y := x >> 15
-	return (x ^ y) - y
+func (r PairRule) UnitType(x rune) rune {
+	// Name the type after the smaller unit of the pair so both map to the same value
+	if partner, ok := r.partners[x]; ok && partner < x {
+		return partner
+	}
+	return x
+}
+
+// react fully reacts polymer under rule. If trace is non-nil, every
+// intermediate polymer is written to it, separated by arrows.
+func react(polymer string, rule ReactionRule, trace io.Writer) string {
+	units := []rune(polymer)
+	stack := make([]rune, 0, len(units))
+
+	if trace != nil {
+		fmt.Fprint(trace, polymer)
+	}
+
+	for i, unit := range units {
+		if n := len(stack); n > 0 && rule.Reacts(stack[n-1], unit) {
+			stack = stack[:n-1]
+			if trace != nil {
+				fmt.Fprintf(trace, " -> %s%s", string(stack), string(units[i+1:]))
+			}
+			continue
+		}
+		stack = append(stack, unit)
+	}
+
+	if trace != nil {
+		fmt.Fprintln(trace)
+	}
+
+	return string(stack)
 }
 
-var letters = []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z"}
+// unitTypes groups the distinct units in polymer by type, in ascending order
+// of type. Within each group the type's own unit comes first.
+func unitTypes(polymer string, rule ReactionRule) ([]rune, map[rune][]rune) {
+	members := make(map[rune][]rune)
+	seen := make(map[rune]bool)
+
+	for _, unit := range polymer {
+		if seen[unit] {
+			continue
+		}
+		seen[unit] = true
+		t := rule.UnitType(unit)
+		members[t] = append(members[t], unit)
+	}
+
+	types := make([]rune, 0, len(members))
+	for t, units := range members {
+		types = append(types, t)
+		sort.Slice(units, func(i, j int) bool {
+			if (units[i] == t) != (units[j] == t) {
+				return units[i] == t
+			}
+			return units[i] < units[j]
+		})
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
 
-func findShortestAfterSingleExcision(polymer string) (shortest string, removedUnits string) {
-	minLength := len(polymer)
+	return types, members
+}
 
-	for _, letter := range letters {
-		upper := strings.ToUpper(letter)
+func findShortestAfterSingleExcision(polymer string, rule ReactionRule) (shortest string, removedUnits string) {
+	minLength := utf8.RuneCountInString(polymer)
 
-		replacer := strings.NewReplacer(letter, "", upper, "")
-		excised := replacer.Replace(polymer)
+	types, members := unitTypes(polymer, rule)
+	for _, t := range types {
+		excised := strings.Map(func(unit rune) rune {
+			if rule.UnitType(unit) == t {
+				return -1
+			}
+			return unit
+		}, polymer)
 
-		result := react(excised)
-		resultLen := len(result)
+		result := react(excised, rule, nil)
+		resultLen := utf8.RuneCountInString(result)
 
 		if resultLen < minLength {
 			minLength = resultLen
 			shortest = result
-			removedUnits = letter + upper
+			removedUnits = string(members[t])
 		}
 	}
 
@@ -96,16 +198,33 @@ func findShortestAfterSingleExcision(polymer string) (shortest string, removedUn
 }
 
 func main() {
-	filename := "input.txt"
+	filename := flag.String("input", "input.txt", "polymer input file")
+	pairs := flag.String("pairs", "", "comma-separated reacting unit pairs (e.g. \"aA,bB\"); defaults to opposite-case letters")
+	trace := flag.Bool("trace", false, "print every reaction step")
+	flag.Parse()
+
+	var rule ReactionRule = CaseRule{}
+	if *pairs != "" {
+		pairRule, err := parsePairRule(*pairs)
+		if err != nil {
+			log.Fatalf("Error parsing reaction pairs: %s\n", err)
+		}
+		rule = pairRule
+	}
 
-	polymer, err := readInput(filename)
+	polymer, err := readInput(*filename)
 	if err != nil {
-		log.Fatalf("Error reading input from %s: %s\n", filename, err)
+		log.Fatalf("Error reading input from %s: %s\n", *filename, err)
+	}
+
+	var traceWriter io.Writer
+	if *trace {
+		traceWriter = os.Stdout
 	}
 
-	result := react(polymer)
-	fmt.Printf("Resulting polymer has %d units\n", len(result))
+	result := react(polymer, rule, traceWriter)
+	fmt.Printf("Resulting polymer has %d units\n", utf8.RuneCountInString(result))
 
-	shortest, removedUnits := findShortestAfterSingleExcision(polymer)
-	fmt.Printf("Removing %s produced the shortest reacted polymer at %d units\n", removedUnits, len(shortest))
+	shortest, removedUnits := findShortestAfterSingleExcision(polymer, rule)
+	fmt.Printf("Removing %s produced the shortest reacted polymer at %d units\n", removedUnits, utf8.RuneCountInString(shortest))
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// syntheticPoints scatters n distinct points over a size×size square.
+func syntheticPoints(n int, size int64, seed int64) []Point {
+	r := rand.New(rand.NewSource(seed))
+	seen := make(map[Point]bool)
+	points := make([]Point, 0, n)
+	for len(points) < n {
+		p := Point{X: r.Int63n(size), Y: r.Int63n(size)}
+		if !seen[p] {
+			seen[p] = true
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// syntheticCases are the inputs of increasing size the area computations are
+// benchmarked on.
+var syntheticCases = []struct {
+	n    int
+	size int64
+}{
+	{n: 50, size: 400},
+	{n: 100, size: 1000},
+	{n: 200, size: 2000},
+}
+
+// gridMetrics are the metrics partitionBFS supports, by name.
+func gridMetrics() map[string]gridMetric {
+	grids := make(map[string]gridMetric)
+	for name, metric := range metrics {
+		if grid, ok := metric.(gridMetric); ok {
+			grids[name] = grid
+		}
+	}
+	return grids
+}
+
+// TestPartitionBFS checks that the breadth-first partition finds the same
+// largest finite area as the brute-force one on small random inputs.
+func TestPartitionBFS(t *testing.T) {
+	for name, metric := range gridMetrics() {
+		for seed := int64(0); seed < 50; seed++ {
+			n := 2 + int(seed%30)
+			points := syntheticPoints(n, 100, seed)
+			bruteBest, bruteArea := partition(points, metric.Extent(points), metric).largestFiniteArea()
+			bfsBest, bfsArea := partitionBFS(points, metric).largestFiniteArea()
+			if bruteBest != bfsBest || bruteArea != bfsArea {
+				t.Errorf(
+					"%s, %d points from seed %d: brute force found %+v with area %d but BFS found %+v with area %d",
+					name, n, seed, bruteBest, bruteArea, bfsBest, bfsArea,
+				)
+			}
+		}
+	}
+}
+
+func BenchmarkPartition(b *testing.B) {
+	for name, metric := range gridMetrics() {
+		for _, c := range syntheticCases {
+			points := syntheticPoints(c.n, c.size, int64(c.n))
+			b.Run(fmt.Sprintf("%s/%dx%d/%d", name, c.size, c.size, c.n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					partition(points, metric.Extent(points), metric).largestFiniteArea()
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkPartitionBFS(b *testing.B) {
+	for name, metric := range gridMetrics() {
+		for _, c := range syntheticCases {
+			points := syntheticPoints(c.n, c.size, int64(c.n))
+			b.Run(fmt.Sprintf("%s/%dx%d/%d", name, c.size, c.size, c.n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					partitionBFS(points, metric).largestFiniteArea()
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+// partitionBFS labels every location inside the metric's ring by expanding
+// outward from all points at once, one step per round, in O(W·H). The first
+// round to reach a location fixes its distance; if a different point (or a
+// tie) reaches it in that same round, the location becomes a tie too.
+func partitionBFS(points []Point, metric gridMetric) Partition {
+	ring := metric.ring(points)
+	bounds := findBounds(ring)
+	width := bounds.MaxX - bounds.MinX + 1
+	height := bounds.MaxY - bounds.MinY + 1
+
+	index := func(x, y int64) int64 {
+		return (y-bounds.MinY)*width + (x - bounds.MinX)
+	}
+
+	const unvisited = -1
+	const tie = -1
+
+	nearest := make([]int32, width*height)
+	distance := make([]int32, width*height)
+	for i := range distance {
+		distance[i] = unvisited
+	}
+
+	queue := make([]int64, 0, len(points))
+	for i, point := range points {
+		at := index(point.X, point.Y)
+		if distance[at] == 0 {
+			// Duplicate coordinates tie with each other
+			nearest[at] = tie
+			continue
+		}
+		distance[at] = 0
+		nearest[at] = int32(i)
+		queue = append(queue, at)
+	}
+
+	steps := metric.steps()
+	for head := 0; head < len(queue); head++ {
+		at := queue[head]
+		x, y := bounds.MinX+at%width, bounds.MinY+at/width
+
+		for _, step := range steps {
+			nx, ny := x+step.X, y+step.Y
+			if nx < bounds.MinX || nx > bounds.MaxX || ny < bounds.MinY || ny > bounds.MaxY {
+				continue
+			}
+
+			next := index(nx, ny)
+			switch distance[next] {
+			case unvisited:
+				distance[next] = distance[at] + 1
+				nearest[next] = nearest[at]
+				queue = append(queue, next)
+			case distance[at] + 1:
+				if nearest[next] != nearest[at] {
+					nearest[next] = tie
+				}
+			}
+		}
+	}
+
+	infinite := make(map[Point]bool)
+	for _, location := range ring {
+		if closest := nearest[index(location.X, location.Y)]; closest != tie {
+			infinite[points[closest]] = true
+		}
+	}
+
+	return Partition{
+		Points:   points,
+		Bounds:   bounds,
+		Nearest:  nearest,
+		Infinite: infinite,
+	}
+}
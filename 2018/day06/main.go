@@ -2,11 +2,13 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 type Point struct {
@@ -90,107 +92,229 @@ func findBounds(points []Point) Bounds {
 	return bounds
 }
 
-func findMostIsolatedPoint(points []Point) (Point, uint64) {
-	areaByPoint := make(map[Point]uint64)
-	infinite := make(map[Point]bool)
-
-	bounds := findBounds(points)
-
-	for x := bounds.MinX; x <= bounds.MaxX; x++ {
-		for y := bounds.MinY; y <= bounds.MaxY; y++ {
-			current := Point{X: x, Y: y}
-
-			var smallestDistance int64 = math.MaxInt64
-			var closestPoint *Point
-
-			for _, point := range points {
-				distance := manhattanDistance(point, current)
+// Partition records which point is nearest to each location within Bounds.
+type Partition struct {
+	Points []Point
+	Bounds Bounds
+	// Nearest holds an index into Points for each location in row-major
+	// order, or -1 where two or more points are tied
+	Nearest  []int32
+	Infinite map[Point]bool
+}
 
-				if distance < smallestDistance {
-					smallestDistance = distance
-					closestPoint = &Point{X: point.X, Y: point.Y}
-				} else if distance == smallestDistance {
-					// Tie between 2+ points
-					closestPoint = nil
-				}
+// partition checks every location in bounds against every point, which takes
+// O(W·H·N) but works for any metric and any bounds.
+func partition(points []Point, bounds Bounds, metric Metric) Partition {
+	width := bounds.MaxX - bounds.MinX + 1
+	height := bounds.MaxY - bounds.MinY + 1
+
+	nearest := make([]int32, 0, width*height)
+	for y := bounds.MinY; y <= bounds.MaxY; y++ {
+		for x := bounds.MinX; x <= bounds.MaxX; x++ {
+			closest, ok := nearestPoint(points, Point{X: x, Y: y}, metric)
+			if !ok {
+				closest = -1
 			}
+			nearest = append(nearest, int32(closest))
+		}
+	}
 
-			if closestPoint == nil {
-				continue
-			}
+	return Partition{
+		Points:   points,
+		Bounds:   bounds,
+		Nearest:  nearest,
+		Infinite: metric.Unbounded(points),
+	}
+}
 
-			if x == bounds.MinX || x == bounds.MaxX || y == bounds.MinY || y == bounds.MaxY {
-				infinite[*closestPoint] = true
-			} else {
-				areaByPoint[*closestPoint]++
-			}
+// At returns the index of the point nearest to (x, y), or -1 for a tie.
+func (p Partition) At(x, y int64) int {
+	width := p.Bounds.MaxX - p.Bounds.MinX + 1
+	return int(p.Nearest[(y-p.Bounds.MinY)*width+(x-p.Bounds.MinX)])
+}
+
+func (p Partition) largestFiniteArea() (Point, uint64) {
+	areas := make([]uint64, len(p.Points))
+	for _, closest := range p.Nearest {
+		if closest >= 0 {
+			areas[closest]++
 		}
 	}
 
 	var mostIsolatedPoint Point
 	var maxArea uint64
-	for point, area := range areaByPoint {
-		if infinite[point] {
+	for i, area := range areas {
+		if p.Infinite[p.Points[i]] {
 			continue
 		}
 		if area > maxArea {
 			maxArea = area
-			mostIsolatedPoint = point
+			mostIsolatedPoint = p.Points[i]
 		}
 	}
 
 	return mostIsolatedPoint, maxArea
 }
 
-func findSafestRegionArea(points []Point) (area uint64) {
-	seen := make(map[Point]bool)
+func findMostIsolatedPoint(points []Point, metric Metric) (Point, uint64) {
+	if grid, ok := metric.(gridMetric); ok {
+		return partitionBFS(points, grid).largestFiniteArea()
+	}
+	return partition(points, metric.Extent(points), metric).largestFiniteArea()
+}
 
-	queue := make([]Point, 0)
-	// Starting point doesn't matter, just pick the first of the ones provided
-	queue = append(queue, points[0])
+// axisDistances answers "what is the sum of |v - c| over all coordinates c on
+// this axis" in O(log N) using the sorted coordinates and their prefix sums.
+type axisDistances struct {
+	sorted []int64
+	prefix []int64
+}
 
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
+func newAxisDistances(values []int64) axisDistances {
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
 
-		if seen[current] {
-			continue
+	prefix := make([]int64, len(sorted)+1)
+	for i, v := range sorted {
+		prefix[i+1] = prefix[i] + v
+	}
+
+	return axisDistances{sorted: sorted, prefix: prefix}
+}
+
+func (a axisDistances) sumTo(v int64) int64 {
+	n := int64(len(a.sorted))
+	// k coordinates are <= v and contribute v - c; the rest contribute c - v
+	k := int64(sort.Search(len(a.sorted), func(i int) bool { return a.sorted[i] > v }))
+	below := v*k - a.prefix[k]
+	above := (a.prefix[n] - a.prefix[k]) - v*(n-k)
+	return below + above
+}
+
+// findSafestRegionArea counts the locations whose total distance to all
+// points is less than threshold.
+//
+// Every metric here is a norm, so the total distance is convex: the region is
+// convex, each column of it is a contiguous run, and the columns themselves
+// are contiguous. Starting from the column with the smallest total, scan
+// outward in each direction until a column has nothing under threshold.
+func findSafestRegionArea(points []Point, threshold int64, metric Metric) (area uint64) {
+	if len(points) == 0 {
+		return 0
+	}
+
+	total := metric.TotalDistance(points)
+	limit := float64(threshold)
+
+	// Every location in the region is within threshold of each point along
+	// both axes, which bounds the searches below
+	bounds := findBounds(points)
+
+	columnMinimum := func(x int64) (int64, float64) {
+		y := firstNonDecreasing(bounds.MinY-threshold, bounds.MaxY+threshold, func(y int64) float64 {
+			return total(Point{X: x, Y: y})
+		})
+		return y, total(Point{X: x, Y: y})
+	}
+
+	countColumn := func(x int64) (uint64, bool) {
+		bestY, best := columnMinimum(x)
+		if best >= limit {
+			return 0, false
 		}
-		seen[current] = true
 
-		if totalManhattanDistance(current, points) < 10000 {
-			area++
-			queue = append(
-				queue,
-				Point{X: current.X + 1, Y: current.Y},
-				Point{X: current.X - 1, Y: current.Y},
-				Point{X: current.X, Y: current.Y + 1},
-				Point{X: current.X, Y: current.Y - 1},
-			)
+		var count uint64 = 1
+		for y := bestY + 1; total(Point{X: x, Y: y}) < limit; y++ {
+			count++
+		}
+		for y := bestY - 1; total(Point{X: x, Y: y}) < limit; y-- {
+			count++
+		}
+		return count, true
+	}
+
+	bestX := firstNonDecreasing(bounds.MinX-threshold, bounds.MaxX+threshold, func(x int64) float64 {
+		_, best := columnMinimum(x)
+		return best
+	})
+
+	for x := bestX; ; x++ {
+		count, ok := countColumn(x)
+		if !ok {
+			break
+		}
+		area += count
+	}
+	for x := bestX - 1; ; x-- {
+		count, ok := countColumn(x)
+		if !ok {
+			break
 		}
+		area += count
 	}
 
 	return
 }
 
-func totalManhattanDistance(ref Point, points []Point) (distance int64) {
-	for _, p := range points {
-		distance += manhattanDistance(ref, p)
+// firstNonDecreasing binary searches [lo, hi] for the minimum of a convex f:
+// the first v where f stops decreasing.
+func firstNonDecreasing(lo, hi int64, f func(int64) float64) int64 {
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if f(mid+1) >= f(mid) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
 	}
-	return
+	return lo
 }
 
 func main() {
-	filename := "input.txt"
+	filename := flag.String("input", "input.txt", "coordinates input file")
+	threshold := flag.Int64("threshold", 10000, "exclusive upper bound on total distance for the safe region")
+	metricName := flag.String("metric", "manhattan", "distance metric: manhattan, chebyshev or euclidean")
+	ascii := flag.Bool("ascii", false, "print the nearest-coordinate partition as letters")
+	pngFile := flag.String("png", "", "write the partition and safe region to this PNG file")
+	scale := flag.Int("scale", 2, "pixels per location in the PNG")
+	flag.Parse()
+
+	metric, err := metricByName(*metricName)
+	if err != nil {
+		log.Fatalf("Error choosing metric: %s\n", err)
+	}
 
-	points, err := readInput(filename)
+	points, err := readInput(*filename)
 	if err != nil {
-		log.Fatalf("Error reading input from %s: %s\n", filename, err)
+		log.Fatalf("Error reading input from %s: %s\n", *filename, err)
 	}
 
-	mostIsolatedPoint, area := findMostIsolatedPoint(points)
+	mostIsolatedPoint, area := findMostIsolatedPoint(points, metric)
 	fmt.Printf("Most isolated point is %+v with area %d\n", mostIsolatedPoint, area)
 
-	area = findSafestRegionArea(points)
-	fmt.Printf("Area of region containing all locations with total distance < 10000: %d\n", area)
+	area = findSafestRegionArea(points, *threshold, metric)
+	fmt.Printf("Area of region containing all locations with total distance < %d: %d\n", *threshold, area)
+
+	if *ascii {
+		if err := renderASCII(os.Stdout, partition(points, pad(findBounds(points), 1), metric)); err != nil {
+			log.Fatalf("Error rendering partition: %s\n", err)
+		}
+	}
+
+	if *pngFile != "" {
+		extent := metric.Extent(points)
+		// Leave some room around the finite regions so infinite ones visibly run off the edge
+		margin := (extent.MaxX - extent.MinX + extent.MaxY - extent.MinY) / 20
+		total := metric.TotalDistance(points)
+		inRegion := func(location Point) bool {
+			return total(location) < float64(*threshold)
+		}
+
+		img := renderImage(partition(points, pad(extent, margin), metric), *scale, inRegion)
+		if err := writePNG(*pngFile, img); err != nil {
+			log.Fatalf("Error writing %s: %s\n", *pngFile, err)
+		}
+		fmt.Printf("Wrote partition to %s\n", *pngFile)
+	}
 }
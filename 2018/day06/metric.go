@@ -0,0 +1,402 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Metric is a distance function over grid locations, along with the knowledge
+// needed to tell which points' nearest-location regions are infinite.
+type Metric interface {
+	Distance(p, q Point) float64
+	// TotalDistance returns a function computing the sum of distances from a
+	// location to every one of points.
+	TotalDistance(points []Point) func(Point) float64
+	// Unbounded reports which of points have regions of infinite area.
+	Unbounded(points []Point) map[Point]bool
+	// Extent returns bounds that contain every finite region.
+	Extent(points []Point) Bounds
+}
+
+// gridMetric is a Metric whose distance is the fewest moves between two
+// locations using a fixed set of steps, so regions can be found by
+// breadth-first search. Its ring surrounds every finite region, and a point's
+// region is infinite exactly when it claims a location on the ring.
+type gridMetric interface {
+	Metric
+	steps() []Point
+	ring(points []Point) []Point
+}
+
+var metrics = map[string]Metric{
+	"manhattan": Manhattan{},
+	"chebyshev": Chebyshev{},
+	"euclidean": Euclidean{},
+}
+
+func metricByName(name string) (Metric, error) {
+	metric, ok := metrics[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(metrics))
+		for name := range metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown metric \"%s\" (choose from %s)", name, strings.Join(names, ", "))
+	}
+	return metric, nil
+}
+
+// nearestPoint finds the index of the single point closest to location, or
+// reports false if two or more points are tied for closest.
+func nearestPoint(points []Point, location Point, metric Metric) (int, bool) {
+	closest := -1
+	smallestDistance := math.Inf(1)
+	tied := true
+
+	for i, point := range points {
+		distance := metric.Distance(point, location)
+
+		if distance < smallestDistance {
+			smallestDistance = distance
+			closest = i
+			tied = false
+		} else if distance == smallestDistance {
+			// Tie between 2+ points
+			tied = true
+		}
+	}
+
+	return closest, !tied
+}
+
+// claimants returns the points that are strictly nearest to any of locations.
+func claimants(points []Point, locations []Point, metric Metric) map[Point]bool {
+	claimed := make(map[Point]bool)
+	for _, location := range locations {
+		if closest, ok := nearestPoint(points, location, metric); ok {
+			claimed[points[closest]] = true
+		}
+	}
+	return claimed
+}
+
+// Manhattan is the taxicab distance |dx| + |dy|.
+type Manhattan struct{}
+
+func (Manhattan) Distance(p, q Point) float64 {
+	return float64(manhattanDistance(p, q))
+}
+
+func (Manhattan) TotalDistance(points []Point) func(Point) float64 {
+	xs := make([]int64, len(points))
+	ys := make([]int64, len(points))
+	for i, p := range points {
+		xs[i] = p.X
+		ys[i] = p.Y
+	}
+	xDistances := newAxisDistances(xs)
+	yDistances := newAxisDistances(ys)
+
+	return func(location Point) float64 {
+		return float64(xDistances.sumTo(location.X) + yDistances.sumTo(location.Y))
+	}
+}
+
+// ring returns the locations just outside the bounding box. Past the box,
+// stepping further away adds the same amount to every point's distance, so
+// whoever claims a location there keeps claiming forever.
+func (Manhattan) ring(points []Point) []Point {
+	bounds := findBounds(points)
+
+	ring := make([]Point, 0)
+	for x := bounds.MinX - 1; x <= bounds.MaxX+1; x++ {
+		ring = append(ring, Point{X: x, Y: bounds.MinY - 1}, Point{X: x, Y: bounds.MaxY + 1})
+	}
+	for y := bounds.MinY; y <= bounds.MaxY; y++ {
+		ring = append(ring, Point{X: bounds.MinX - 1, Y: y}, Point{X: bounds.MaxX + 1, Y: y})
+	}
+
+	return ring
+}
+
+func (m Manhattan) steps() []Point {
+	return []Point{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}}
+}
+
+func (m Manhattan) Unbounded(points []Point) map[Point]bool {
+	return claimants(points, m.ring(points), m)
+}
+
+func (Manhattan) Extent(points []Point) Bounds {
+	return findBounds(points)
+}
+
+// Chebyshev is the chessboard distance max(|dx|, |dy|). Rotating coordinates
+// to u = x+y, v = x-y turns it into (|du| + |dv|) / 2, i.e. half of a
+// Manhattan distance, which is what makes it tractable.
+type Chebyshev struct{}
+
+func (Chebyshev) Distance(p, q Point) float64 {
+	dx, dy := abs64(p.X-q.X), abs64(p.Y-q.Y)
+	if dx > dy {
+		return float64(dx)
+	}
+	return float64(dy)
+}
+
+func rotate(p Point) Point {
+	return Point{X: p.X + p.Y, Y: p.X - p.Y}
+}
+
+func (Chebyshev) TotalDistance(points []Point) func(Point) float64 {
+	us := make([]int64, len(points))
+	vs := make([]int64, len(points))
+	for i, p := range points {
+		r := rotate(p)
+		us[i] = r.X
+		vs[i] = r.Y
+	}
+	uDistances := newAxisDistances(us)
+	vDistances := newAxisDistances(vs)
+
+	return func(location Point) float64 {
+		r := rotate(location)
+		return float64(uDistances.sumTo(r.X)+vDistances.sumTo(r.Y)) / 2
+	}
+}
+
+// ring returns the locations just outside the bounding box in rotated
+// coordinates. Only locations with u and v of equal parity exist on the grid,
+// and a diagonal step moves u or v by 2, so the ring is 2 wide.
+func (Chebyshev) ring(points []Point) []Point {
+	rotated := make([]Point, len(points))
+	for i, p := range points {
+		rotated[i] = rotate(p)
+	}
+	box := findBounds(rotated)
+
+	ring := make([]Point, 0)
+	for u := box.MinX - 2; u <= box.MaxX+2; u++ {
+		for v := box.MinY - 2; v <= box.MaxY+2; v++ {
+			if (u-v)%2 != 0 {
+				continue
+			}
+			if u >= box.MinX && u <= box.MaxX && v >= box.MinY && v <= box.MaxY {
+				continue
+			}
+			ring = append(ring, Point{X: (u + v) / 2, Y: (u - v) / 2})
+		}
+	}
+
+	return ring
+}
+
+func (m Chebyshev) steps() []Point {
+	return []Point{
+		{X: 1}, {X: -1}, {Y: 1}, {Y: -1},
+		{X: 1, Y: 1}, {X: 1, Y: -1}, {X: -1, Y: 1}, {X: -1, Y: -1},
+	}
+}
+
+func (m Chebyshev) Unbounded(points []Point) map[Point]bool {
+	return claimants(points, m.ring(points), m)
+}
+
+func (Chebyshev) Extent(points []Point) Bounds {
+	rotated := make([]Point, len(points))
+	for i, p := range points {
+		rotated[i] = rotate(p)
+	}
+	box := findBounds(rotated)
+
+	return Bounds{
+		MinX: floorDiv2(box.MinX + box.MinY),
+		MinY: floorDiv2(box.MinX - box.MaxY),
+		MaxX: ceilDiv2(box.MaxX + box.MaxY),
+		MaxY: ceilDiv2(box.MaxX - box.MinY),
+	}
+}
+
+func floorDiv2(x int64) int64 {
+	return x >> 1
+}
+
+func ceilDiv2(x int64) int64 {
+	return -((-x) >> 1)
+}
+
+// Euclidean is the straight-line distance.
+type Euclidean struct{}
+
+func (Euclidean) Distance(p, q Point) float64 {
+	dx, dy := float64(p.X-q.X), float64(p.Y-q.Y)
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+func (Euclidean) TotalDistance(points []Point) func(Point) float64 {
+	return func(location Point) (distance float64) {
+		for _, p := range points {
+			distance += Euclidean{}.Distance(p, location)
+		}
+		return
+	}
+}
+
+// Unbounded returns the points on the boundary of the convex hull, including
+// those in the middle of a hull edge. Those are exactly the points whose
+// Voronoi cells are unbounded; each one's cell contains the lattice ray
+// pointing straight out of the hull from it.
+func (Euclidean) Unbounded(points []Point) map[Point]bool {
+	hull := convexHull(points)
+	unbounded := make(map[Point]bool)
+
+	if len(hull) < 3 {
+		// All points are collinear, so every cell is unbounded
+		for _, p := range points {
+			unbounded[p] = true
+		}
+		return unbounded
+	}
+
+	for _, p := range points {
+		for i := range hull {
+			a, b := hull[i], hull[(i+1)%len(hull)]
+			if cross(a, b, p) == 0 && within(p, a, b) {
+				unbounded[p] = true
+				break
+			}
+		}
+	}
+
+	return unbounded
+}
+
+// Extent clips each bounded Voronoi cell out of a huge square and takes the
+// bounds of all of them together. Finite cells can poke outside the points'
+// bounding box, so the bounding box alone is not enough.
+func (Euclidean) Extent(points []Point) Bounds {
+	extent := findBounds(points)
+	unbounded := Euclidean{}.Unbounded(points)
+
+	span := float64(extent.MaxX-extent.MinX+extent.MaxY-extent.MinY) + 1
+	// Voronoi vertices of integer points sit within a few span³ of them
+	huge := 16 * span * span * span
+
+	for _, p := range points {
+		if unbounded[p] {
+			continue
+		}
+
+		px, py := float64(p.X), float64(p.Y)
+		cell := [][2]float64{
+			{px - huge, py - huge},
+			{px + huge, py - huge},
+			{px + huge, py + huge},
+			{px - huge, py + huge},
+		}
+		for _, q := range points {
+			if q == p {
+				continue
+			}
+			// Keep the side of the perpendicular bisector nearer to p
+			nx, ny := float64(q.X-p.X), float64(q.Y-p.Y)
+			c := (float64(q.X*q.X+q.Y*q.Y) - float64(p.X*p.X+p.Y*p.Y)) / 2
+			cell = clipPolygon(cell, nx, ny, c)
+		}
+
+		for _, vertex := range cell {
+			extent.MinX = min64(extent.MinX, int64(math.Floor(vertex[0])))
+			extent.MaxX = max64(extent.MaxX, int64(math.Ceil(vertex[0])))
+			extent.MinY = min64(extent.MinY, int64(math.Floor(vertex[1])))
+			extent.MaxY = max64(extent.MaxY, int64(math.Ceil(vertex[1])))
+		}
+	}
+
+	return extent
+}
+
+// clipPolygon keeps the part of polygon where nx*x + ny*y <= c.
+func clipPolygon(polygon [][2]float64, nx, ny, c float64) [][2]float64 {
+	clipped := make([][2]float64, 0, len(polygon)+1)
+
+	for i, current := range polygon {
+		next := polygon[(i+1)%len(polygon)]
+		currentSide := nx*current[0] + ny*current[1] - c
+		nextSide := nx*next[0] + ny*next[1] - c
+
+		if currentSide <= 0 {
+			clipped = append(clipped, current)
+		}
+		if (currentSide < 0 && nextSide > 0) || (currentSide > 0 && nextSide < 0) {
+			t := currentSide / (currentSide - nextSide)
+			clipped = append(clipped, [2]float64{
+				current[0] + t*(next[0]-current[0]),
+				current[1] + t*(next[1]-current[1]),
+			})
+		}
+	}
+
+	return clipped
+}
+
+// convexHull returns the strict convex hull (no collinear points) in
+// counter-clockwise order, using Andrew's monotone chain.
+func convexHull(points []Point) []Point {
+	sorted := make([]Point, len(points))
+	copy(sorted, points)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X != sorted[j].X {
+			return sorted[i].X < sorted[j].X
+		}
+		return sorted[i].Y < sorted[j].Y
+	})
+
+	if len(sorted) < 3 {
+		return sorted
+	}
+
+	hull := make([]Point, 0, 2*len(sorted))
+	for _, p := range sorted {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		p := sorted[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+
+	return hull[:len(hull)-1]
+}
+
+func cross(o, a, b Point) int64 {
+	return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+}
+
+// within reports whether p lies in the bounding box of segment ab.
+func within(p, a, b Point) bool {
+	return p.X >= min64(a.X, b.X) && p.X <= max64(a.X, b.X) &&
+		p.Y >= min64(a.Y, b.Y) && p.Y <= max64(a.Y, b.Y)
+}
+
+func min64(x, y int64) int64 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func max64(x, y int64) int64 {
+	if x > y {
+		return x
+	}
+	return y
+}
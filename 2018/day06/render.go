@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+)
+
+// regionLetter names the region of the i-th point the way the puzzle does,
+// wrapping around after z.
+func regionLetter(i int, upper bool) byte {
+	if upper {
+		return 'A' + byte(i%26)
+	}
+	return 'a' + byte(i%26)
+}
+
+// renderASCII draws the partition like the puzzle description: lowercase
+// letters for claimed locations, uppercase for the points themselves and '.'
+// for ties.
+func renderASCII(w io.Writer, p Partition) error {
+	isPoint := make(map[Point]int)
+	for i, point := range p.Points {
+		isPoint[point] = i
+	}
+
+	out := bufio.NewWriter(w)
+
+	for y := p.Bounds.MinY; y <= p.Bounds.MaxY; y++ {
+		for x := p.Bounds.MinX; x <= p.Bounds.MaxX; x++ {
+			if i, ok := isPoint[Point{X: x, Y: y}]; ok {
+				out.WriteByte(regionLetter(i, true))
+				continue
+			}
+
+			closest := p.At(x, y)
+			if closest < 0 {
+				out.WriteByte('.')
+			} else {
+				out.WriteByte(regionLetter(closest, false))
+			}
+		}
+		out.WriteByte('\n')
+	}
+
+	return out.Flush()
+}
+
+var (
+	tieColor    = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	pointColor  = color.RGBA{A: 0xff}
+	regionColor = color.RGBA{A: 0xff}
+)
+
+// regionColors picks a distinct hue for each point by stepping around the
+// color wheel by the golden angle.
+func regionColors(n int) []color.RGBA {
+	colors := make([]color.RGBA, n)
+	for i := range colors {
+		hue := math.Mod(float64(i)*137.508, 360)
+		colors[i] = hsv(hue, 0.55, 0.95)
+	}
+	return colors
+}
+
+func hsv(h, s, v float64) color.RGBA {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return color.RGBA{
+		R: uint8((r + m) * 255),
+		G: uint8((g + m) * 255),
+		B: uint8((b + m) * 255),
+		A: 0xff,
+	}
+}
+
+func darken(c color.RGBA) color.RGBA {
+	return color.RGBA{R: c.R / 2, G: c.G / 2, B: c.B / 2, A: c.A}
+}
+
+// renderImage draws the partition with scale×scale pixels per location.
+// Each region gets its own color and infinite regions are hatched with
+// diagonal stripes. If inRegion is non-nil, the outline of the locations it
+// accepts (i.e. the safe region) is drawn over the top.
+func renderImage(p Partition, scale int, inRegion func(Point) bool) *image.RGBA {
+	width := int(p.Bounds.MaxX-p.Bounds.MinX+1) * scale
+	height := int(p.Bounds.MaxY-p.Bounds.MinY+1) * scale
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	colors := regionColors(len(p.Points))
+
+	for y := p.Bounds.MinY; y <= p.Bounds.MaxY; y++ {
+		for x := p.Bounds.MinX; x <= p.Bounds.MaxX; x++ {
+			px := int(x-p.Bounds.MinX) * scale
+			py := int(y-p.Bounds.MinY) * scale
+
+			closest := p.At(x, y)
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					c := tieColor
+					if closest >= 0 {
+						c = colors[closest]
+						// Stripes run in image space so they stay continuous across cells
+						if p.Infinite[p.Points[closest]] && (px+dx+py+dy)%8 < 2 {
+							c = darken(c)
+						}
+					}
+					img.SetRGBA(px+dx, py+dy, c)
+				}
+			}
+		}
+	}
+
+	if inRegion != nil {
+		outlineRegion(img, p.Bounds, scale, inRegion)
+	}
+
+	// Points go last so they're never hidden, and at least 3 pixels wide
+	size := scale
+	if size < 3 {
+		size = 3
+	}
+	for _, point := range p.Points {
+		if point.X < p.Bounds.MinX || point.X > p.Bounds.MaxX || point.Y < p.Bounds.MinY || point.Y > p.Bounds.MaxY {
+			continue
+		}
+		cx := int(point.X-p.Bounds.MinX)*scale + scale/2
+		cy := int(point.Y-p.Bounds.MinY)*scale + scale/2
+		for dy := -size / 2; dy <= size/2; dy++ {
+			for dx := -size / 2; dx <= size/2; dx++ {
+				if image.Pt(cx+dx, cy+dy).In(img.Rect) {
+					img.SetRGBA(cx+dx, cy+dy, pointColor)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// outlineRegion draws a line along every edge between a location inside the
+// region and one outside it.
+func outlineRegion(img *image.RGBA, bounds Bounds, scale int, inRegion func(Point) bool) {
+	for y := bounds.MinY; y <= bounds.MaxY; y++ {
+		for x := bounds.MinX; x <= bounds.MaxX; x++ {
+			if !inRegion(Point{X: x, Y: y}) {
+				continue
+			}
+
+			px := int(x-bounds.MinX) * scale
+			py := int(y-bounds.MinY) * scale
+			last := scale - 1
+
+			for i := 0; i < scale; i++ {
+				if !inRegion(Point{X: x, Y: y - 1}) {
+					img.SetRGBA(px+i, py, regionColor)
+				}
+				if !inRegion(Point{X: x, Y: y + 1}) {
+					img.SetRGBA(px+i, py+last, regionColor)
+				}
+				if !inRegion(Point{X: x - 1, Y: y}) {
+					img.SetRGBA(px, py+i, regionColor)
+				}
+				if !inRegion(Point{X: x + 1, Y: y}) {
+					img.SetRGBA(px+last, py+i, regionColor)
+				}
+			}
+		}
+	}
+}
+
+func writePNG(filename string, img image.Image) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("creating image file %s: %s", filename, err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("encoding PNG: %s", err)
+	}
+
+	return f.Close()
+}
+
+// pad grows bounds by margin locations on every side.
+func pad(bounds Bounds, margin int64) Bounds {
+	return Bounds{
+		MinX: bounds.MinX - margin,
+		MinY: bounds.MinY - margin,
+		MaxX: bounds.MaxX + margin,
+		MaxY: bounds.MaxY + margin,
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// criticalPath finds the chain of dependent steps with the greatest total
+// duration. No number of workers can finish sooner than that, and with
+// unlimited workers timeWork takes exactly that long. Ties go to the
+// alphabetically first step.
+func criticalPath(dependencies DependencyGraph, duration func(step string) uint) ([]string, uint, error) {
+	order, err := findBuildOrder(dependencies)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// finish[step] is the earliest step can finish given unlimited workers
+	finish := make(map[string]uint, len(order))
+	previous := make(map[string]string, len(order))
+
+	for _, step := range order {
+		var start uint
+		for _, dep := range dependencies.Dependencies(step) {
+			if finish[dep] > start {
+				start = finish[dep]
+				previous[step] = dep
+			}
+		}
+		finish[step] = start + duration(step)
+	}
+
+	var last string
+	var total uint
+	for _, step := range dependencies.Steps() {
+		if last == "" || finish[step] > total {
+			last = step
+			total = finish[step]
+		}
+	}
+
+	path := make([]string, 0)
+	for step := last; step != ""; step = previous[step] {
+		path = append(path, step)
+	}
+	for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+		path[l], path[r] = path[r], path[l]
+	}
+
+	return path, total, nil
+}
+
+// graphAnnotations is what the exporters mark on the graph: each step's
+// position in the build order and duration, and the critical path.
+type graphAnnotations struct {
+	position     map[string]int
+	duration     func(step string) uint
+	criticalStep StringSet
+	criticalEdge map[Requirement]bool
+}
+
+func annotate(dependencies DependencyGraph, config WorkConfig) (graphAnnotations, error) {
+	order, err := findBuildOrder(dependencies)
+	if err != nil {
+		return graphAnnotations{}, err
+	}
+	path, _, err := criticalPath(dependencies, config.duration)
+	if err != nil {
+		return graphAnnotations{}, err
+	}
+
+	a := graphAnnotations{
+		position:     make(map[string]int, len(order)),
+		duration:     config.duration,
+		criticalStep: make(StringSet),
+		criticalEdge: make(map[Requirement]bool),
+	}
+	for i, step := range order {
+		a.position[step] = i + 1
+	}
+	for i, step := range path {
+		a.criticalStep[step] = true
+		if i > 0 {
+			a.criticalEdge[Requirement{Before: path[i-1], After: step}] = true
+		}
+	}
+
+	return a, nil
+}
+
+func (a graphAnnotations) label(step string) string {
+	return fmt.Sprintf("%s\n#%d, %ds", step, a.position[step], a.duration(step))
+}
+
+// renderDOT writes the graph in Graphviz DOT format. Each step is labeled
+// with its place in the build order and its duration, and the critical path
+// is drawn in bold red.
+func renderDOT(w io.Writer, dependencies DependencyGraph, config WorkConfig) error {
+	a, err := annotate(dependencies, config)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, "digraph steps {")
+	fmt.Fprintln(out, "\trankdir=LR;")
+	fmt.Fprintln(out, "\tnode [shape=box];")
+
+	for _, step := range dependencies.Steps() {
+		style := ""
+		if a.criticalStep[step] {
+			style = ", color=red, penwidth=2"
+		}
+		fmt.Fprintf(out, "\t%s [label=%s%s];\n", strconv.Quote(step), strconv.Quote(a.label(step)), style)
+	}
+
+	for _, step := range dependencies.Steps() {
+		for _, dependent := range dependencies.Dependents(step) {
+			style := ""
+			if a.criticalEdge[Requirement{Before: step, After: dependent}] {
+				style = " [color=red, penwidth=2]"
+			}
+			fmt.Fprintf(out, "\t%s -> %s%s;\n", strconv.Quote(step), strconv.Quote(dependent), style)
+		}
+	}
+
+	fmt.Fprintln(out, "}")
+
+	return out.Flush()
+}
+
+// renderMermaid writes the graph as a Mermaid flowchart, annotated the same
+// way as renderDOT.
+func renderMermaid(w io.Writer, dependencies DependencyGraph, config WorkConfig) error {
+	a, err := annotate(dependencies, config)
+	if err != nil {
+		return err
+	}
+
+	// Mermaid is picky about node IDs, so number the steps instead
+	steps := dependencies.Steps()
+	id := make(map[string]string, len(steps))
+	for i, step := range steps {
+		id[step] = fmt.Sprintf("s%d", i)
+	}
+
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, "flowchart LR")
+
+	critical := make([]string, 0)
+	for _, step := range steps {
+		label := strings.ReplaceAll(a.label(step), "\n", "<br/>")
+		label = strings.ReplaceAll(label, `"`, "#quot;")
+		fmt.Fprintf(out, "    %s[\"%s\"]\n", id[step], label)
+		if a.criticalStep[step] {
+			critical = append(critical, id[step])
+		}
+	}
+
+	criticalLinks := make([]string, 0)
+	link := 0
+	for _, step := range steps {
+		for _, dependent := range dependencies.Dependents(step) {
+			fmt.Fprintf(out, "    %s --> %s\n", id[step], id[dependent])
+			if a.criticalEdge[Requirement{Before: step, After: dependent}] {
+				criticalLinks = append(criticalLinks, strconv.Itoa(link))
+			}
+			link++
+		}
+	}
+
+	if len(critical) > 0 {
+		fmt.Fprintln(out, "    classDef critical stroke:#d00,stroke-width:3px;")
+		fmt.Fprintf(out, "    class %s critical;\n", strings.Join(critical, ","))
+	}
+	if len(criticalLinks) > 0 {
+		fmt.Fprintf(out, "    linkStyle %s stroke:#d00,stroke-width:3px;\n", strings.Join(criticalLinks, ","))
+	}
+
+	return out.Flush()
+}
+
+func writeExport(filename string, render func(io.Writer) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("creating file %s: %s", filename, err)
+	}
+	defer f.Close()
+
+	if err := render(f); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type StringSet map[string]bool
+
+// Requirement says that step Before must be finished before step After can
+// begin.
+type Requirement struct {
+	Before, After string
+}
+
+// DependencyGraph holds the steps and their requirements. It is never
+// modified after construction; code that needs to track progress through the
+// graph does so with a buildState instead.
+type DependencyGraph struct {
+	steps        []string
+	dependencies map[string]StringSet
+	dependents   map[string]StringSet
+}
+
+func NewDependencyGraph(requirements []Requirement) DependencyGraph {
+	g := DependencyGraph{
+		dependencies: make(map[string]StringSet),
+		dependents:   make(map[string]StringSet),
+	}
+
+	for _, r := range requirements {
+		g.addStep(r.Before)
+		g.addStep(r.After)
+		g.dependencies[r.After][r.Before] = true
+		g.dependents[r.Before][r.After] = true
+	}
+	sort.Strings(g.steps)
+
+	return g
+}
+
+func (g *DependencyGraph) addStep(step string) {
+	if _, ok := g.dependencies[step]; ok {
+		return
+	}
+	g.steps = append(g.steps, step)
+	g.dependencies[step] = make(StringSet)
+	g.dependents[step] = make(StringSet)
+}
+
+// Steps returns every step in alphabetical order.
+func (g DependencyGraph) Steps() []string {
+	steps := make([]string, len(g.steps))
+	copy(steps, g.steps)
+	return steps
+}
+
+// Dependencies returns the steps that must finish before step can begin, in
+// alphabetical order.
+func (g DependencyGraph) Dependencies(step string) []string {
+	return sortedKeys(g.dependencies[step])
+}
+
+// Dependents returns the steps that directly wait on step, in alphabetical
+// order.
+func (g DependencyGraph) Dependents(step string) []string {
+	return sortedKeys(g.dependents[step])
+}
+
+func (g DependencyGraph) Len() int {
+	return len(g.steps)
+}
+
+// Clone returns a deep copy of the graph.
+func (g DependencyGraph) Clone() DependencyGraph {
+	clone := DependencyGraph{
+		steps:        g.Steps(),
+		dependencies: make(map[string]StringSet, len(g.dependencies)),
+		dependents:   make(map[string]StringSet, len(g.dependents)),
+	}
+	for step, deps := range g.dependencies {
+		clone.dependencies[step] = deps.clone()
+	}
+	for step, deps := range g.dependents {
+		clone.dependents[step] = deps.clone()
+	}
+	return clone
+}
+
+// InDegrees returns the number of direct dependencies of each step.
+func (g DependencyGraph) InDegrees() map[string]int {
+	inDegrees := make(map[string]int, len(g.dependencies))
+	for step, deps := range g.dependencies {
+		inDegrees[step] = len(deps)
+	}
+	return inDegrees
+}
+
+func (s StringSet) clone() StringSet {
+	clone := make(StringSet, len(s))
+	for k, v := range s {
+		clone[k] = v
+	}
+	return clone
+}
+
+func sortedKeys(s StringSet) []string {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CycleError is returned when the requirements can't all be satisfied because
+// some steps (transitively) depend on themselves.
+type CycleError struct {
+	// Steps lists the steps around the cycle; each one must finish before the
+	// next, and the last before the first
+	Steps []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("dependency cycle: %s -> %s", strings.Join(e.Steps, " -> "), e.Steps[0])
+}
+
+// buildState tracks progress through a DependencyGraph: how many unfinished
+// dependencies each step still has, and which steps are ready to begin.
+type buildState struct {
+	graph     DependencyGraph
+	remaining map[string]int
+	available []string
+	finished  int
+}
+
+func newBuildState(g DependencyGraph) *buildState {
+	s := &buildState{
+		graph:     g,
+		remaining: g.InDegrees(),
+		available: make([]string, 0),
+	}
+	for _, step := range g.steps {
+		if s.remaining[step] == 0 {
+			s.available = append(s.available, step)
+		}
+	}
+	return s
+}
+
+// Available returns the steps that can begin now, in alphabetical order.
+func (s *buildState) Available() []string {
+	return s.available
+}
+
+func (s *buildState) Done() bool {
+	return s.finished == s.graph.Len()
+}
+
+// Start takes step off the list of available steps.
+func (s *buildState) Start(step string) {
+	i := sort.SearchStrings(s.available, step)
+	if i < len(s.available) && s.available[i] == step {
+		s.available = append(s.available[:i], s.available[i+1:]...)
+	}
+}
+
+// Finish marks step complete, making available any dependents that were only
+// waiting on it.
+func (s *buildState) Finish(step string) {
+	s.finished++
+	for dependent := range s.graph.dependents[step] {
+		s.remaining[dependent]--
+		if s.remaining[dependent] == 0 {
+			i := sort.SearchStrings(s.available, dependent)
+			s.available = append(s.available, "")
+			copy(s.available[i+1:], s.available[i:])
+			s.available[i] = dependent
+		}
+	}
+}
+
+// cycle finds a cycle among the steps that never became available. Every such
+// step has an unfinished dependency that is also stuck, so following those
+// dependencies must eventually revisit a step.
+func (s *buildState) cycle() error {
+	stuck := func(step string) bool {
+		return s.remaining[step] > 0
+	}
+
+	var current string
+	for _, step := range s.graph.steps {
+		if stuck(step) {
+			current = step
+			break
+		}
+	}
+	if current == "" {
+		return nil
+	}
+
+	position := make(map[string]int)
+	path := make([]string, 0)
+	for {
+		if i, ok := position[current]; ok {
+			// path runs against the requirements, so reverse it
+			cycle := path[i:]
+			for l, r := 0, len(cycle)-1; l < r; l, r = l+1, r-1 {
+				cycle[l], cycle[r] = cycle[r], cycle[l]
+			}
+			return &CycleError{Steps: cycle}
+		}
+		position[current] = len(path)
+		path = append(path, current)
+
+		for _, dep := range s.graph.Dependencies(current) {
+			if stuck(dep) {
+				current = dep
+				break
+			}
+		}
+	}
+}
@@ -2,30 +2,28 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 )
 
-type StringSet map[string]bool
-type DependencyGraph map[string]StringSet
-
 func readInput(filename string) (DependencyGraph, error) {
 	path, err := filepath.Abs(filename)
 	if err != nil {
-		return nil, fmt.Errorf("constructing absolute path from %s: %s", filename, err)
+		return DependencyGraph{}, fmt.Errorf("constructing absolute path from %s: %s", filename, err)
 	}
 
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("opening input file %s: %s", path, err)
+		return DependencyGraph{}, fmt.Errorf("opening input file %s: %s", path, err)
 	}
 	defer f.Close()
 
-	dependencies := make(DependencyGraph)
+	requirements := make([]Requirement, 0)
 
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
@@ -37,74 +35,121 @@ func readInput(filename string) (DependencyGraph, error) {
 			&step,
 		)
 		if n != 2 || err != nil {
-			return nil, fmt.Errorf("parsing line: %s", err)
-		}
-
-		if _, ok := dependencies[step]; !ok {
-			dependencies[step] = make(StringSet)
-		}
-		if _, ok := dependencies[dependency]; !ok {
-			dependencies[dependency] = make(StringSet)
+			return DependencyGraph{}, fmt.Errorf("parsing line: %s", err)
 		}
 
-		dependencies[step][dependency] = true
+		requirements = append(requirements, Requirement{Before: dependency, After: step})
 	}
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("reading input file: %s", err)
+		return DependencyGraph{}, fmt.Errorf("reading input file: %s", err)
 	}
 
-	return dependencies, nil
+	return NewDependencyGraph(requirements), nil
 }
 
-func findBuildOrder(dependencies DependencyGraph) []string {
-	order := make([]string, 0, len(dependencies))
+// findBuildOrder returns the order in which to complete the steps, always
+// choosing the alphabetically first available step. The graph is not modified.
+func findBuildOrder(dependencies DependencyGraph) ([]string, error) {
+	order := make([]string, 0, dependencies.Len())
+	state := newBuildState(dependencies)
 
-	for len(dependencies) > 0 {
-		available := findAvailableSteps(dependencies)
+	for !state.Done() {
+		available := state.Available()
+		if len(available) == 0 {
+			return nil, state.cycle()
+		}
 
 		next := available[0]
 		order = append(order, next)
 
-		removeDependency(next, dependencies)
+		state.Start(next)
+		state.Finish(next)
 	}
 
-	return order
+	return order, nil
+}
+
+// WorkConfig describes the crew working through the steps: how many workers
+// there are, how long each step takes and how they choose what to work on. A
+// step takes BaseDuration plus StepDuration(step) seconds.
+type WorkConfig struct {
+	Workers      int
+	BaseDuration uint
+	StepDuration func(step string) uint
+	// Policy defaults to Alphabetical if nil
+	Policy Policy
 }
 
-func findAvailableSteps(dependencies DependencyGraph) []string {
-	steps := make([]string, 0)
-	for step, deps := range dependencies {
-		if len(deps) == 0 {
-			steps = append(steps, step)
+func (c WorkConfig) duration(step string) uint {
+	return c.BaseDuration + c.StepDuration(step)
+}
+
+// alphabetPosition numbers steps the way spreadsheet columns are numbered:
+// A=1 through Z=26, then AA=27, AB=28 and so on. Case is ignored, as is
+// anything that isn't a letter.
+func alphabetPosition(step string) (position uint) {
+	for _, r := range strings.ToUpper(step) {
+		if r < 'A' || r > 'Z' {
+			continue
 		}
+		position = position*26 + uint(r-'A'+1)
 	}
-	sort.Strings(steps)
-	return steps
+	return
 }
 
-func removeDependency(step string, dependencies DependencyGraph) {
-	delete(dependencies, step)
-	for _, deps := range dependencies {
-		delete(deps, step)
+var (
+	// puzzleConfig is the crew from part two of the puzzle
+	puzzleConfig = WorkConfig{Workers: 5, BaseDuration: 60, StepDuration: alphabetPosition}
+	// exampleConfig is the smaller crew from the puzzle's worked example
+	exampleConfig = WorkConfig{Workers: 2, BaseDuration: 0, StepDuration: alphabetPosition}
+)
+
+// formatOrder runs single-letter steps together like the puzzle does, but
+// separates longer step names so the order stays readable.
+func formatOrder(order []string) string {
+	for _, step := range order {
+		if len(step) > 1 {
+			return strings.Join(order, " ")
+		}
 	}
+	return strings.Join(order, "")
 }
 
 type Worker struct {
 	Step          string
-	TimeRemaining uint8
+	Started       uint
+	TimeRemaining uint
 }
 
 func (w Worker) Working() bool {
 	return w.Step != ""
 }
 
-func timeWork(dependencies DependencyGraph, workerCount int) (total uint) {
-	workers := make([]Worker, workerCount)
-	assigned := make(StringSet)
+// timeWork works out how config's crew completes every step, starting the
+// step chosen by its policy whenever a worker is idle, and returns the
+// resulting schedule. Rather than ticking one second at a time,
+// the clock jumps straight to the next time a worker finishes.
+func timeWork(dependencies DependencyGraph, config WorkConfig) (Schedule, error) {
+	if config.Workers < 1 {
+		return Schedule{}, fmt.Errorf("need at least 1 worker, got %d", config.Workers)
+	}
+
+	schedule := Schedule{
+		Workers:     config.Workers,
+		Assignments: make([]Assignment, 0, dependencies.Len()),
+	}
+	var total uint
 
-	for len(dependencies) > 0 || len(assigned) > 0 {
-		available := findAvailableSteps(dependencies)
+	policy := config.Policy
+	if policy == nil {
+		policy = Alphabetical{}
+	}
 
+	workers := make([]Worker, config.Workers)
+	state := newBuildState(dependencies)
+	working := 0
+
+	for !state.Done() {
 		// Assign work
 		for i := 0; i < len(workers); i++ {
 			w := &workers[i]
@@ -113,27 +158,33 @@ func timeWork(dependencies DependencyGraph, workerCount int) (total uint) {
 				continue
 			}
 
-			// Pick an available step; must not already be assigned
-			var chosenStep string
-			for _, step := range available {
-				if !assigned[step] {
-					chosenStep = step
-					break
-				}
-			}
-
-			if chosenStep == "" {
-				// All available steps are already assigned
+			available := state.Available()
+			if len(available) == 0 {
 				break
 			}
 
+			chosenStep := policy.Pick(available, dependencies, config)
+			state.Start(chosenStep)
+
 			w.Step = chosenStep
-			w.TimeRemaining = timeForStep(chosenStep)
+			w.Started = total
+			w.TimeRemaining = config.duration(chosenStep)
+			working++
+		}
+
+		if working == 0 {
+			// Nothing is running and nothing can start
+			return Schedule{}, state.cycle()
+		}
 
-			assigned[chosenStep] = true
+		// Advance the clock to the next completion
+		elapsed := ^uint(0)
+		for _, w := range workers {
+			if w.Working() && w.TimeRemaining < elapsed {
+				elapsed = w.TimeRemaining
+			}
 		}
 
-		// Tick the clock
 		for i := 0; i < len(workers); i++ {
 			w := &workers[i]
 
@@ -141,43 +192,127 @@ func timeWork(dependencies DependencyGraph, workerCount int) (total uint) {
 				continue
 			}
 
-			w.TimeRemaining--
+			w.TimeRemaining -= elapsed
 			if w.TimeRemaining == 0 {
 				completedStep := w.Step
 				w.Step = ""
-				delete(assigned, completedStep)
-				removeDependency(completedStep, dependencies)
+				working--
+				state.Finish(completedStep)
+
+				schedule.Assignments = append(schedule.Assignments, Assignment{
+					Worker: i,
+					Step:   completedStep,
+					Start:  w.Started,
+					End:    total + elapsed,
+				})
 			}
 		}
 
-		total++
+		total += elapsed
 	}
 
-	return
-}
-
-func timeForStep(step string) uint8 {
-	return step[0] - 4
+	schedule.Total = total
+	return schedule, nil
 }
 
 func main() {
-	filename := "input.txt"
+	filename := flag.String("input", "input.txt", "step requirements input file")
+	example := flag.Bool("example", false, "use the worked example's crew (2 workers, no base duration) instead of the puzzle's")
+	workers := flag.Int("workers", 0, "number of workers (overrides the crew's)")
+	base := flag.Int("base", -1, "base seconds per step (overrides the crew's)")
+	table := flag.Bool("table", false, "print the second-by-second schedule table")
+	gantt := flag.Int("gantt", 0, "print a Gantt chart of the schedule at most this many columns wide")
+	svgFile := flag.String("svg", "", "write the schedule as an SVG timeline to this file")
+	critical := flag.Bool("critical", false, "print the critical path and its total duration")
+	dotFile := flag.String("dot", "", "write the dependency graph in Graphviz DOT format to this file")
+	mermaidFile := flag.String("mermaid", "", "write the dependency graph as a Mermaid flowchart to this file")
+	policyName := flag.String("policy", "alphabetical", "how idle workers choose a step: alphabetical, longest or dependents")
+	optimal := flag.Bool("optimal", false, "search for the fastest possible schedule (small graphs only)")
+	flag.Parse()
+
+	config := puzzleConfig
+	if *example {
+		config = exampleConfig
+	}
+	if *workers > 0 {
+		config.Workers = *workers
+	}
+	if *base >= 0 {
+		config.BaseDuration = uint(*base)
+	}
+	policy, err := policyByName(*policyName)
+	if err != nil {
+		log.Fatalf("Error choosing policy: %s\n", err)
+	}
+	config.Policy = policy
 
-	dependencies, err := readInput(filename)
+	dependencies, err := readInput(*filename)
 	if err != nil {
-		log.Fatalf("Error reading input from %s: %s\n", filename, err)
+		log.Fatalf("Error reading input from %s: %s\n", *filename, err)
 	}
 
-	buildOrder := findBuildOrder(dependencies)
-	fmt.Println("Build order:", strings.Join(buildOrder, ""))
+	buildOrder, err := findBuildOrder(dependencies)
+	if err != nil {
+		log.Fatalf("Error finding build order: %s\n", err)
+	}
+	fmt.Println("Build order:", formatOrder(buildOrder))
 
-	// Read dependencies again because it's modified by findBuildOrder
-	dependencies, err = readInput(filename)
+	schedule, err := timeWork(dependencies, config)
 	if err != nil {
-		log.Fatalf("Error reading input from %s: %s\n", filename, err)
+		log.Fatalf("Error timing work: %s\n", err)
+	}
+	fmt.Printf("Total time for %d parallel workers: %d\n", config.Workers, schedule.Total)
+
+	if *optimal {
+		best, err := findOptimalSchedule(dependencies, config)
+		if err != nil {
+			log.Fatalf("Error finding optimal schedule: %s\n", err)
+		}
+		fmt.Printf("Optimal time for %d parallel workers: %d (%s policy takes %d longer)\n",
+			config.Workers, best.Total, *policyName, schedule.Total-best.Total)
+		schedule = best
 	}
 
-	workerCount := 5
-	parallelTime := timeWork(dependencies, workerCount)
-	fmt.Printf("Total time for %d parallel workers: %d\n", workerCount, parallelTime)
+	if *critical {
+		path, duration, err := criticalPath(dependencies, config.duration)
+		if err != nil {
+			log.Fatalf("Error finding critical path: %s\n", err)
+		}
+		fmt.Printf("Critical path: %s (%d seconds)\n", strings.Join(path, " -> "), duration)
+	}
+	if *dotFile != "" {
+		err := writeExport(*dotFile, func(w io.Writer) error {
+			return renderDOT(w, dependencies, config)
+		})
+		if err != nil {
+			log.Fatalf("Error writing %s: %s\n", *dotFile, err)
+		}
+		fmt.Printf("Wrote dependency graph to %s\n", *dotFile)
+	}
+	if *mermaidFile != "" {
+		err := writeExport(*mermaidFile, func(w io.Writer) error {
+			return renderMermaid(w, dependencies, config)
+		})
+		if err != nil {
+			log.Fatalf("Error writing %s: %s\n", *mermaidFile, err)
+		}
+		fmt.Printf("Wrote dependency graph to %s\n", *mermaidFile)
+	}
+
+	if *table {
+		if err := renderTable(os.Stdout, schedule); err != nil {
+			log.Fatalf("Error rendering schedule table: %s\n", err)
+		}
+	}
+	if *gantt > 0 {
+		if err := renderGantt(os.Stdout, schedule, *gantt); err != nil {
+			log.Fatalf("Error rendering Gantt chart: %s\n", err)
+		}
+	}
+	if *svgFile != "" {
+		if err := writeSVG(*svgFile, schedule); err != nil {
+			log.Fatalf("Error writing %s: %s\n", *svgFile, err)
+		}
+		fmt.Printf("Wrote schedule to %s\n", *svgFile)
+	}
 }
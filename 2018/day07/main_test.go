@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// exampleRequirements are the steps from the puzzle description.
+var exampleRequirements = []Requirement{
+	{Before: "C", After: "A"},
+	{Before: "C", After: "F"},
+	{Before: "A", After: "B"},
+	{Before: "A", After: "D"},
+	{Before: "B", After: "E"},
+	{Before: "D", After: "E"},
+	{Before: "F", After: "E"},
+}
+
+func TestExample(t *testing.T) {
+	dependencies := NewDependencyGraph(exampleRequirements)
+
+	order, err := findBuildOrder(dependencies)
+	if err != nil {
+		t.Fatalf("finding build order: %s", err)
+	}
+	if got := formatOrder(order); got != "CABDFE" {
+		t.Errorf("build order is %s, want CABDFE", got)
+	}
+
+	schedule, err := timeWork(dependencies, exampleConfig)
+	if err != nil {
+		t.Fatalf("timing work: %s", err)
+	}
+	if schedule.Total != 15 {
+		t.Errorf("total time is %d, want 15", schedule.Total)
+	}
+}
+
+func TestCycle(t *testing.T) {
+	tests := []struct {
+		name         string
+		requirements []Requirement
+		want         []string
+	}{
+		{
+			name:         "self-loop",
+			requirements: []Requirement{{Before: "A", After: "A"}, {Before: "B", After: "C"}},
+			want:         []string{"A"},
+		},
+		{
+			name: "3-cycle",
+			requirements: []Requirement{
+				{Before: "A", After: "B"},
+				{Before: "B", After: "C"},
+				{Before: "C", After: "A"},
+				{Before: "C", After: "D"},
+				{Before: "E", After: "A"},
+			},
+			want: []string{"B", "C", "A"},
+		},
+	}
+
+	for _, test := range tests {
+		dependencies := NewDependencyGraph(test.requirements)
+
+		_, orderErr := findBuildOrder(dependencies)
+		_, timeErr := timeWork(dependencies, exampleConfig)
+		for _, err := range []error{orderErr, timeErr} {
+			var cycle *CycleError
+			if !errors.As(err, &cycle) {
+				t.Errorf("%s: got error %v, want a *CycleError", test.name, err)
+				continue
+			}
+			if !reflect.DeepEqual(cycle.Steps, test.want) {
+				t.Errorf("%s: cycle is %v, want %v", test.name, cycle.Steps, test.want)
+			}
+		}
+	}
+}
+
+// randomGraph makes a graph of up to n single-letter steps whose
+// requirements only run from earlier letters to later ones, so it has no
+// cycles.
+func randomGraph(r *rand.Rand, n int) DependencyGraph {
+	requirements := make([]Requirement, 0)
+	for after := 1; after < n; after++ {
+		// Link every step to an earlier one some of the time, so there are
+		// independent chains as well as joins
+		for before := 0; before < after; before++ {
+			if r.Intn(3) == 0 {
+				requirements = append(requirements, Requirement{
+					Before: string(rune('A' + before)),
+					After:  string(rune('A' + after)),
+				})
+			}
+		}
+	}
+	return NewDependencyGraph(requirements)
+}
+
+// checkSchedule reports whether s does every step once, for as long as it
+// takes, after its dependencies, without giving a worker two steps at once.
+func checkSchedule(s Schedule, dependencies DependencyGraph, config WorkConfig) error {
+	end := make(map[string]uint)
+	for _, a := range s.Assignments {
+		if _, ok := end[a.Step]; ok {
+			return fmt.Errorf("step %s is done twice", a.Step)
+		}
+		if a.End-a.Start != config.duration(a.Step) {
+			return fmt.Errorf("step %s takes %d seconds, want %d", a.Step, a.End-a.Start, config.duration(a.Step))
+		}
+		if a.End > s.Total {
+			return fmt.Errorf("step %s ends at %d, after the total %d", a.Step, a.End, s.Total)
+		}
+		end[a.Step] = a.End
+	}
+	if len(end) != dependencies.Len() {
+		return fmt.Errorf("%d steps are done, want %d", len(end), dependencies.Len())
+	}
+
+	for _, a := range s.Assignments {
+		for _, dep := range dependencies.Dependencies(a.Step) {
+			if end[dep] > a.Start {
+				return fmt.Errorf("step %s starts at %d, before %s ends at %d", a.Step, a.Start, dep, end[dep])
+			}
+		}
+	}
+
+	for _, assignments := range s.byWorker() {
+		for i := 1; i < len(assignments); i++ {
+			if assignments[i].Start < assignments[i-1].End {
+				return fmt.Errorf("worker %d starts %s before finishing %s", assignments[i].Worker+1, assignments[i].Step, assignments[i-1].Step)
+			}
+		}
+	}
+
+	return nil
+}
+
+// TestFindOptimalSchedule checks that the optimal schedule is valid, never
+// slower than any policy, and never faster than the critical path or the
+// total work shared out between the workers.
+func TestFindOptimalSchedule(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		dependencies := randomGraph(r, 2+r.Intn(11))
+		config := WorkConfig{
+			Workers:      1 + r.Intn(3),
+			BaseDuration: uint(r.Intn(4)),
+			StepDuration: alphabetPosition,
+		}
+
+		best, err := findOptimalSchedule(dependencies, config)
+		if err != nil {
+			t.Fatalf("graph %d: %s", i, err)
+		}
+		if err := checkSchedule(best, dependencies, config); err != nil {
+			t.Fatalf("graph %d with %d workers: optimal schedule is invalid: %s", i, config.Workers, err)
+		}
+
+		for name, policy := range policies {
+			config := config
+			config.Policy = policy
+			schedule, err := timeWork(dependencies, config)
+			if err != nil {
+				t.Fatalf("graph %d, %s policy: %s", i, name, err)
+			}
+			if best.Total > schedule.Total {
+				t.Errorf("graph %d with %d workers: optimal time %d is slower than the %s policy's %d",
+					i, config.Workers, best.Total, name, schedule.Total)
+			}
+		}
+
+		_, longest, err := criticalPath(dependencies, config.duration)
+		if err != nil {
+			t.Fatalf("graph %d: finding critical path: %s", i, err)
+		}
+		if best.Total < longest {
+			t.Errorf("graph %d: optimal time %d is faster than the critical path's %d", i, best.Total, longest)
+		}
+
+		var work uint
+		for _, step := range dependencies.Steps() {
+			work += config.duration(step)
+		}
+		if shared := (work + uint(config.Workers) - 1) / uint(config.Workers); best.Total < shared {
+			t.Errorf("graph %d with %d workers: optimal time %d is faster than %d seconds of work allows",
+				i, config.Workers, best.Total, work)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Policy decides which of the available steps an idle worker starts next.
+type Policy interface {
+	// Pick chooses from available, which is non-empty and in alphabetical
+	// order.
+	Pick(available []string, dependencies DependencyGraph, config WorkConfig) string
+}
+
+// Alphabetical is the puzzle's policy: the alphabetically first step.
+type Alphabetical struct{}
+
+func (Alphabetical) Pick(available []string, dependencies DependencyGraph, config WorkConfig) string {
+	return available[0]
+}
+
+// LongestFirst starts the step that takes longest, so that short steps can
+// fill in gaps later.
+type LongestFirst struct{}
+
+func (LongestFirst) Pick(available []string, dependencies DependencyGraph, config WorkConfig) string {
+	best := available[0]
+	for _, step := range available[1:] {
+		if config.duration(step) > config.duration(best) {
+			best = step
+		}
+	}
+	return best
+}
+
+// MostDependentsFirst starts the step that the most other steps are waiting
+// on, directly or indirectly, to unblock as much work as possible.
+type MostDependentsFirst struct{}
+
+func (MostDependentsFirst) Pick(available []string, dependencies DependencyGraph, config WorkConfig) string {
+	best := available[0]
+	bestCount := countDependents(dependencies, best)
+	for _, step := range available[1:] {
+		if count := countDependents(dependencies, step); count > bestCount {
+			best = step
+			bestCount = count
+		}
+	}
+	return best
+}
+
+// countDependents counts the steps that can't begin until step is finished.
+func countDependents(dependencies DependencyGraph, step string) int {
+	seen := make(StringSet)
+	stack := []string{step}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for dependent := range dependencies.dependents[current] {
+			if !seen[dependent] {
+				seen[dependent] = true
+				stack = append(stack, dependent)
+			}
+		}
+	}
+	return len(seen)
+}
+
+var policies = map[string]Policy{
+	"alphabetical": Alphabetical{},
+	"longest":      LongestFirst{},
+	"dependents":   MostDependentsFirst{},
+}
+
+func policyByName(name string) (Policy, error) {
+	policy, ok := policies[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(policies))
+		for name := range policies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown policy \"%s\" (choose from %s)", name, strings.Join(names, ", "))
+	}
+	return policy, nil
+}
+
+// maxOptimalSteps caps the graphs findOptimalSchedule will search, since the
+// search is exponential in the number of steps.
+const maxOptimalSteps = 16
+
+// findOptimalSchedule searches every way of assigning steps to workers for
+// one that finishes soonest.
+//
+// It only makes decisions at time 0 and when a step finishes: any schedule
+// can have each start moved back to the latest such moment without breaking
+// anything, so nothing better is missed. At each decision it tries every set
+// of available steps that fits on the idle workers, including deliberately
+// leaving workers idle, which a greedy policy never does. Branches are cut
+// off when a lower bound on their finishing time (the longest remaining chain
+// of steps, or the remaining work spread over all workers) can't beat the
+// best schedule found so far.
+func findOptimalSchedule(dependencies DependencyGraph, config WorkConfig) (Schedule, error) {
+	steps := dependencies.Steps()
+	if len(steps) > maxOptimalSteps {
+		return Schedule{}, fmt.Errorf("graph has %d steps; searching for an optimal schedule only works up to %d", len(steps), maxOptimalSteps)
+	}
+
+	// Start from the greedy schedule so there's a bound to prune against
+	greedy, err := timeWork(dependencies, config)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	s := newScheduleSearch(dependencies, config, steps)
+	s.best = greedy.Total
+	s.bestStarts = make([]uint, len(steps))
+	for _, a := range greedy.Assignments {
+		s.bestStarts[s.index[a.Step]] = a.Start
+	}
+
+	s.search(0, 0, 0, nil)
+
+	return s.schedule(), nil
+}
+
+type runningStep struct {
+	step int
+	end  uint
+}
+
+type scheduleSearch struct {
+	config   WorkConfig
+	steps    []string
+	index    map[string]int
+	duration []uint
+	// requires[i] is the set of steps that must finish before step i
+	requires []uint64
+	// tail[i] is the longest chain of durations starting with step i
+	tail []uint
+
+	starts     []uint
+	best       uint
+	bestStarts []uint
+}
+
+func newScheduleSearch(dependencies DependencyGraph, config WorkConfig, steps []string) *scheduleSearch {
+	s := &scheduleSearch{
+		config:   config,
+		steps:    steps,
+		index:    make(map[string]int, len(steps)),
+		duration: make([]uint, len(steps)),
+		requires: make([]uint64, len(steps)),
+		tail:     make([]uint, len(steps)),
+		starts:   make([]uint, len(steps)),
+	}
+	for i, step := range steps {
+		s.index[step] = i
+		s.duration[i] = config.duration(step)
+	}
+	for i, step := range steps {
+		for _, dep := range dependencies.Dependencies(step) {
+			s.requires[i] |= 1 << uint(s.index[dep])
+		}
+	}
+
+	// The graph has no cycles (timeWork already checked), so build order
+	// reversed visits every step after all of its dependents
+	order, _ := findBuildOrder(dependencies)
+	for i := len(order) - 1; i >= 0; i-- {
+		step := s.index[order[i]]
+		var longest uint
+		for _, dependent := range dependencies.Dependents(order[i]) {
+			if t := s.tail[s.index[dependent]]; t > longest {
+				longest = t
+			}
+		}
+		s.tail[step] = s.duration[step] + longest
+	}
+
+	return s
+}
+
+// search explores every schedule continuing from time t, where finished and
+// started are bitsets of steps and running holds the steps in progress.
+func (s *scheduleSearch) search(t uint, finished, started uint64, running []runningStep) {
+	all := uint64(1)<<uint(len(s.steps)) - 1
+	if finished == all {
+		if t < s.best {
+			s.best = t
+			copy(s.bestStarts, s.starts)
+		}
+		return
+	}
+
+	if s.lowerBound(t, started, running) >= s.best {
+		return
+	}
+
+	available := make([]int, 0)
+	for i := range s.steps {
+		bit := uint64(1) << uint(i)
+		if started&bit == 0 && s.requires[i]&finished == s.requires[i] {
+			available = append(available, i)
+		}
+	}
+
+	idle := s.config.Workers - len(running)
+	if idle > len(available) {
+		idle = len(available)
+	}
+
+	// Try the biggest sets first; they tend to find good schedules quickly
+	for size := idle; size >= 0; size-- {
+		if size == 0 && len(running) == 0 {
+			// Starting nothing with nothing running would never make progress
+			break
+		}
+		forEachSubset(available, size, func(chosen []int) {
+			next := make([]runningStep, len(running), len(running)+len(chosen))
+			copy(next, running)
+			nextStarted := started
+			for _, step := range chosen {
+				next = append(next, runningStep{step: step, end: t + s.duration[step]})
+				nextStarted |= 1 << uint(step)
+				s.starts[step] = t
+			}
+
+			// Jump to the next time something finishes
+			soonest := next[0].end
+			for _, r := range next[1:] {
+				if r.end < soonest {
+					soonest = r.end
+				}
+			}
+			nextFinished := finished
+			stillRunning := make([]runningStep, 0, len(next))
+			for _, r := range next {
+				if r.end == soonest {
+					nextFinished |= 1 << uint(r.step)
+				} else {
+					stillRunning = append(stillRunning, r)
+				}
+			}
+
+			s.search(soonest, nextFinished, nextStarted, stillRunning)
+		})
+	}
+}
+
+func (s *scheduleSearch) lowerBound(t uint, started uint64, running []runningStep) uint {
+	bound := t
+	var work uint
+
+	for _, r := range running {
+		if end := r.end + s.tail[r.step] - s.duration[r.step]; end > bound {
+			bound = end
+		}
+		work += r.end - t
+	}
+	for i := range s.steps {
+		if started&(1<<uint(i)) != 0 {
+			continue
+		}
+		if end := t + s.tail[i]; end > bound {
+			bound = end
+		}
+		work += s.duration[i]
+	}
+
+	workers := uint(s.config.Workers)
+	if spread := t + (work+workers-1)/workers; spread > bound {
+		bound = spread
+	}
+
+	return bound
+}
+
+// forEachSubset calls f with every size-element subset of items, in
+// lexicographic order. f must not keep the slice it's given.
+func forEachSubset(items []int, size int, f func([]int)) {
+	chosen := make([]int, 0, size)
+	var recurse func(from int)
+	recurse = func(from int) {
+		if len(chosen) == size {
+			f(chosen)
+			return
+		}
+		for i := from; i <= len(items)-(size-len(chosen)); i++ {
+			chosen = append(chosen, items[i])
+			recurse(i + 1)
+			chosen = chosen[:len(chosen)-1]
+		}
+	}
+	recurse(0)
+}
+
+// schedule turns the best start times found into a Schedule, giving each
+// step to the lowest-numbered worker that's free when it starts.
+func (s *scheduleSearch) schedule() Schedule {
+	order := make([]int, len(s.steps))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		if s.bestStarts[order[a]] != s.bestStarts[order[b]] {
+			return s.bestStarts[order[a]] < s.bestStarts[order[b]]
+		}
+		return order[a] < order[b]
+	})
+
+	schedule := Schedule{
+		Workers:     s.config.Workers,
+		Assignments: make([]Assignment, 0, len(s.steps)),
+		Total:       s.best,
+	}
+	freeAt := make([]uint, s.config.Workers)
+	for _, step := range order {
+		start := s.bestStarts[step]
+		for worker := range freeAt {
+			if freeAt[worker] <= start {
+				end := start + s.duration[step]
+				freeAt[worker] = end
+				schedule.Assignments = append(schedule.Assignments, Assignment{
+					Worker: worker,
+					Step:   s.steps[step],
+					Start:  start,
+					End:    end,
+				})
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(schedule.Assignments, func(i, j int) bool {
+		a, b := schedule.Assignments[i], schedule.Assignments[j]
+		if a.End != b.End {
+			return a.End < b.End
+		}
+		return a.Worker < b.Worker
+	})
+
+	return schedule
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Assignment records that a worker spent [Start, End) on a step.
+type Assignment struct {
+	Worker     int
+	Step       string
+	Start, End uint
+}
+
+// Schedule is the full record of how a crew completed the steps.
+type Schedule struct {
+	Workers int
+	// Assignments are in order of completion, with simultaneous completions
+	// in worker order
+	Assignments []Assignment
+	Total       uint
+}
+
+// IdlePeriods returns the stretches of time during which a worker had nothing
+// to do, as Assignments with an empty Step, sorted by worker then start.
+func (s Schedule) IdlePeriods() []Assignment {
+	byWorker := s.byWorker()
+
+	idle := make([]Assignment, 0)
+	for worker, assignments := range byWorker {
+		var free uint
+		for _, a := range assignments {
+			if a.Start > free {
+				idle = append(idle, Assignment{Worker: worker, Start: free, End: a.Start})
+			}
+			free = a.End
+		}
+		if s.Total > free {
+			idle = append(idle, Assignment{Worker: worker, Start: free, End: s.Total})
+		}
+	}
+
+	return idle
+}
+
+// byWorker splits the assignments up by worker, each list sorted by start.
+func (s Schedule) byWorker() [][]Assignment {
+	byWorker := make([][]Assignment, s.Workers)
+	for _, a := range s.Assignments {
+		byWorker[a.Worker] = append(byWorker[a.Worker], a)
+	}
+	for _, assignments := range byWorker {
+		sort.Slice(assignments, func(i, j int) bool { return assignments[i].Start < assignments[j].Start })
+	}
+	return byWorker
+}
+
+// stepAt returns the step worker is doing during second t, or "" if idle.
+func stepAt(assignments []Assignment, t uint) string {
+	for _, a := range assignments {
+		if a.Start <= t && t < a.End {
+			return a.Step
+		}
+	}
+	return ""
+}
+
+// renderTable prints one row per second, like the puzzle's worked example.
+func renderTable(w io.Writer, s Schedule) error {
+	byWorker := s.byWorker()
+
+	columnWidth := len("Worker 1")
+	for _, a := range s.Assignments {
+		if len(a.Step) > columnWidth {
+			columnWidth = len(a.Step)
+		}
+	}
+
+	out := bufio.NewWriter(w)
+
+	header := "Second"
+	for i := range byWorker {
+		header += fmt.Sprintf("   %-*s", columnWidth, fmt.Sprintf("Worker %d", i+1))
+	}
+	fmt.Fprintln(out, header+"   Done")
+
+	done := make([]string, 0, len(s.Assignments))
+	next := 0
+	for t := uint(0); t <= s.Total; t++ {
+		for next < len(s.Assignments) && s.Assignments[next].End <= t {
+			done = append(done, s.Assignments[next].Step)
+			next++
+		}
+
+		row := fmt.Sprintf("%4d     ", t)
+		for _, assignments := range byWorker {
+			step := stepAt(assignments, t)
+			if step == "" {
+				step = "."
+			}
+			row += fmt.Sprintf("   %-*s", columnWidth, step)
+		}
+		row += formatOrder(done)
+		fmt.Fprintln(out, strings.TrimRight(row, " "))
+	}
+
+	return out.Flush()
+}
+
+// renderGantt prints one line per worker, squeezing the timeline into at most
+// width columns. The first letter of a step's name marks the column in which
+// it starts, dashes show it continuing, and dots show the worker idle. If any
+// name is longer than one letter, a legend after the chart lists each
+// worker's steps in order, since their first letters can be the same.
+func renderGantt(w io.Writer, s Schedule, width int) error {
+	if width < 1 {
+		return fmt.Errorf("chart must be at least 1 column wide, got %d", width)
+	}
+
+	secondsPerColumn := (s.Total + uint(width) - 1) / uint(width)
+	if secondsPerColumn == 0 {
+		secondsPerColumn = 1
+	}
+	columns := int((s.Total + secondsPerColumn - 1) / secondsPerColumn)
+
+	out := bufio.NewWriter(w)
+	byWorker := s.byWorker()
+
+	legend := false
+	for i, assignments := range byWorker {
+		line := make([]rune, columns)
+		for c := range line {
+			line[c] = '.'
+		}
+
+		for _, a := range assignments {
+			first := int(a.Start / secondsPerColumn)
+			last := int((a.End - 1) / secondsPerColumn)
+			if a.End == a.Start {
+				last = first
+			}
+			for c := first + 1; c <= last && c < columns; c++ {
+				line[c] = '-'
+			}
+			label, size := utf8.DecodeRuneInString(a.Step)
+			if size < len(a.Step) {
+				legend = true
+			}
+			if first < columns {
+				line[first] = label
+			}
+		}
+
+		fmt.Fprintf(out, "Worker %-3d|%s|\n", i+1, string(line))
+	}
+
+	fmt.Fprintf(out, "%*s0%*d\n", 11, "", columns-1, s.Total)
+	fmt.Fprintf(out, "1 column = %d second(s)\n", secondsPerColumn)
+
+	if legend {
+		for i, assignments := range byWorker {
+			steps := make([]string, len(assignments))
+			for j, a := range assignments {
+				steps[j] = fmt.Sprintf("%s (%d-%d)", a.Step, a.Start, a.End)
+			}
+			fmt.Fprintf(out, "Worker %-3d %s\n", i+1, strings.Join(steps, ", "))
+		}
+	}
+
+	return out.Flush()
+}
+
+const (
+	svgWidth     = 1000
+	svgRowHeight = 32
+	svgMargin    = 80
+)
+
+// renderSVG draws the schedule as a timeline with one lane per worker.
+func renderSVG(w io.Writer, s Schedule) error {
+	total := s.Total
+	if total == 0 {
+		total = 1
+	}
+	pxPerSecond := float64(svgWidth) / float64(total)
+	height := svgRowHeight*s.Workers + 2*svgMargin
+
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="12">`+"\n",
+		svgWidth+2*svgMargin, height)
+	fmt.Fprintf(out, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+
+	for i := 0; i < s.Workers; i++ {
+		y := svgMargin + i*svgRowHeight
+		fmt.Fprintf(out, `<text x="%d" y="%d" text-anchor="end" dominant-baseline="middle">Worker %d</text>`+"\n",
+			svgMargin-8, y+svgRowHeight/2, i+1)
+		fmt.Fprintf(out, `<rect x="%d" y="%d" width="%d" height="%d" fill="#eeeeee"/>`+"\n",
+			svgMargin, y+2, svgWidth, svgRowHeight-4)
+	}
+
+	for i, a := range s.Assignments {
+		x := svgMargin + float64(a.Start)*pxPerSecond
+		width := float64(a.End-a.Start) * pxPerSecond
+		y := svgMargin + a.Worker*svgRowHeight
+		hue := math.Mod(float64(i)*137.508, 360)
+
+		fmt.Fprintf(out, `<rect x="%.2f" y="%d" width="%.2f" height="%d" fill="hsl(%.0f, 60%%, 70%%)" stroke="black" stroke-width="0.5">`,
+			x, y+2, width, svgRowHeight-4, hue)
+		fmt.Fprintf(out, `<title>%s: %d-%d</title></rect>`+"\n", html.EscapeString(a.Step), a.Start, a.End)
+		// Only label steps wide enough to hold their name
+		if width >= float64(8*len(a.Step)) {
+			fmt.Fprintf(out, `<text x="%.2f" y="%d" text-anchor="middle" dominant-baseline="middle">%s</text>`+"\n",
+				x+width/2, y+svgRowHeight/2, html.EscapeString(a.Step))
+		}
+	}
+
+	axisY := svgMargin + s.Workers*svgRowHeight + 8
+	fmt.Fprintf(out, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`+"\n",
+		svgMargin, axisY, svgMargin+svgWidth, axisY)
+	tick := tickInterval(total)
+	for t := uint(0); t <= total; t += tick {
+		x := svgMargin + float64(t)*pxPerSecond
+		fmt.Fprintf(out, `<line x1="%.2f" y1="%d" x2="%.2f" y2="%d" stroke="black"/>`+"\n", x, axisY, x, axisY+5)
+		fmt.Fprintf(out, `<text x="%.2f" y="%d" text-anchor="middle">%d</text>`+"\n", x, axisY+18, t)
+	}
+
+	fmt.Fprintln(out, "</svg>")
+
+	return out.Flush()
+}
+
+// tickInterval picks a round number of seconds (1, 2 or 5 times a power of
+// ten) giving around ten ticks across total.
+func tickInterval(total uint) uint {
+	var magnitude uint = 1
+	for {
+		for _, multiple := range []uint{1, 2, 5} {
+			if total/(multiple*magnitude) <= 10 {
+				return multiple * magnitude
+			}
+		}
+		magnitude *= 10
+	}
+}
+
+func writeSVG(filename string, s Schedule) error {
+	return writeExport(filename, func(w io.Writer) error {
+		return renderSVG(w, s)
+	})
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// encodeTree serializes a tree back into the license file format: each node
+// is its child count, its metadata count, its children, then its metadata.
+func encodeTree(root Node) []uint64 {
+	numbers := make([]uint64, 0)
+
+	type frame struct {
+		node *Node
+		next int
+	}
+	numbers = append(numbers, uint64(len(root.Children)), uint64(len(root.Metadata)))
+	stack := []frame{{node: &root}}
+
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+
+		if top.next < len(top.node.Children) {
+			child := &top.node.Children[top.next]
+			top.next++
+			numbers = append(numbers, uint64(len(child.Children)), uint64(len(child.Metadata)))
+			stack = append(stack, frame{node: child})
+			continue
+		}
+
+		numbers = append(numbers, top.node.Metadata...)
+		stack = stack[:len(stack)-1]
+	}
+
+	return numbers
+}
+
+func formatNumbers(numbers []uint64) string {
+	var b strings.Builder
+	for i, n := range numbers {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.FormatUint(n, 10))
+	}
+	return b.String()
+}
+
+// TreeShape limits the trees generateStream makes.
+type TreeShape struct {
+	// MaxDepth is how many levels of children below the root there can be
+	MaxDepth    int
+	MaxChildren int
+	// Every node gets between 1 and MaxMetadata metadata entries, like the
+	// puzzle input
+	MaxMetadata int
+	// Metadata entries range from 0 to MaxValue, so keeping it a little above
+	// MaxChildren exercises both valid and out-of-range child references
+	MaxValue uint64
+}
+
+var defaultShape = TreeShape{MaxDepth: 4, MaxChildren: 3, MaxMetadata: 3, MaxValue: 5}
+
+const maxInt = int(^uint(0) >> 1)
+
+// validate checks that generateStream can draw every count within shape.
+// Any MaxValue works.
+func (shape TreeShape) validate() error {
+	if shape.MaxDepth < 0 || shape.MaxChildren < 0 || shape.MaxMetadata < 0 {
+		return fmt.Errorf("tree shape limits must not be negative, got %+v", shape)
+	}
+	if shape.MaxChildren == maxInt {
+		return fmt.Errorf("at most %d children per node can be generated, got %d", maxInt-1, shape.MaxChildren)
+	}
+	return nil
+}
+
+// nodeLabel names nodes A through Z, then AA, AB and so on.
+func nodeLabel(i int) string {
+	label := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		label = string(rune('A'+(i-1)%26)) + label
+	}
+	return label
+}
+
+// drawTree prints the encoded tree with each node underlined on the line for
+// its depth, like the puzzle description, followed by each node's value.
+func drawTree(w io.Writer, root Node) error {
+	numbers := encodeTree(root)
+	line := formatNumbers(numbers)
+
+	// column[i] is where the i-th number starts in line
+	column := make([]int, len(numbers)+1)
+	for i, n := range numbers {
+		column[i+1] = column[i] + len(strconv.FormatUint(n, 10)) + 1
+	}
+
+	rows := make([][]byte, 0)
+	values := make([]string, 0)
+
+	// Nodes are labeled in the order they start, but their underlines and
+	// values are only known once their children are done. The walk uses an
+	// explicit stack so deep trees can't overflow the goroutine stack.
+	type frame struct {
+		node        *Node
+		label       string
+		index       int
+		start, end  int
+		depth       int
+		childValues []uint64
+	}
+	enter := func(node *Node, start, depth int) frame {
+		index := len(values)
+		values = append(values, "")
+		return frame{node: node, label: nodeLabel(index), index: index, start: start, end: start + 2, depth: depth}
+	}
+	stack := []frame{enter(&root, 0, 0)}
+
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if next := len(top.childValues); next < len(top.node.Children) {
+			stack = append(stack, enter(&top.node.Children[next], top.end, top.depth+1))
+			continue
+		}
+
+		finished := *top
+		stack = stack[:len(stack)-1]
+
+		finished.end += len(finished.node.Metadata)
+		label := finished.label
+		value := nodeValue(*finished.node, finished.childValues)
+		values[finished.index] = fmt.Sprintf("%s = %d", label, value)
+
+		for len(rows) <= finished.depth {
+			rows = append(rows, []byte(strings.Repeat(" ", len(line))))
+		}
+		span := column[finished.end] - column[finished.start] - 1
+		dashes := span - len(label)
+		if dashes < 0 {
+			dashes = 0
+		}
+		underline := []byte(label + strings.Repeat("-", dashes))
+		copy(rows[finished.depth][column[finished.start]:], underline)
+
+		if len(stack) > 0 {
+			parent := &stack[len(stack)-1]
+			parent.end = finished.end
+			parent.childValues = append(parent.childValues, value)
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, line)
+	for _, row := range rows {
+		fmt.Fprintln(out, strings.TrimRight(string(row), " "))
+	}
+	fmt.Fprintln(out)
+	for _, value := range values {
+		fmt.Fprintln(out, value)
+	}
+	return out.Flush()
+}
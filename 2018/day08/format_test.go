@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// generateTree builds a random tree within shape. The same seed always gives
+// the same tree, and the same one generateStream produces.
+func generateTree(seed int64, shape TreeShape) Node {
+	var b treeBuilder
+	// treeBuilder never fails, so neither can this
+	generateStream(seed, shape, &b)
+	return b.Root
+}
+
+// TestRoundTrip checks that encoding then decoding random trees gives back
+// the same tree, and that streaming over the encoding gives the same answers
+// as the decoded tree.
+func TestRoundTrip(t *testing.T) {
+	for seed := int64(0); seed < 1000; seed++ {
+		tree := generateTree(seed, defaultShape)
+		encoded := encodeTree(tree)
+
+		decoded, err := buildTree(encoded)
+		if err != nil {
+			t.Fatalf("seed %d: decoding %s: %s", seed, formatNumbers(encoded), err)
+		}
+		if !reflect.DeepEqual(tree, decoded) {
+			t.Fatalf("seed %d: %s decoded to a different tree", seed, formatNumbers(encoded))
+		}
+		if reencoded := encodeTree(decoded); !reflect.DeepEqual(encoded, reencoded) {
+			t.Fatalf("seed %d: %s re-encoded as %s", seed, formatNumbers(encoded), formatNumbers(reencoded))
+		}
+
+		var checksum checksumVisitor
+		if err := decodeStream(strings.NewReader(formatNumbers(encoded)), &checksum); err != nil {
+			t.Fatalf("seed %d: streaming %s: %s", seed, formatNumbers(encoded), err)
+		}
+		if checksum.Sum != sumMetadata(tree) || checksum.Value != calculateValue(tree) {
+			t.Fatalf(
+				"seed %d: streaming %s gave sum %d and value %d, want %d and %d",
+				seed, formatNumbers(encoded), checksum.Sum, checksum.Value, sumMetadata(tree), calculateValue(tree),
+			)
+		}
+	}
+}
+
+// TestDecodeErrors checks that buildTree and decodeStream report the same
+// errors for every truncation of random trees, for trees with numbers after
+// them, and for counts too big for the input.
+func TestDecodeErrors(t *testing.T) {
+	inputs := [][]uint64{
+		{5, 0, 0, 0},
+		{0, 5, 1, 2},
+		{1, 1, 0, 3},
+		{},
+	}
+	for seed := int64(0); seed < 200; seed++ {
+		encoded := encodeTree(generateTree(seed, defaultShape))
+		for n := 0; n < len(encoded); n++ {
+			inputs = append(inputs, encoded[:n])
+		}
+		inputs = append(inputs, append(encoded, 1, 2, 3))
+	}
+
+	for _, numbers := range inputs {
+		_, treeErr := buildTree(numbers)
+		streamErr := decodeStream(strings.NewReader(formatNumbers(numbers)), &checksumVisitor{})
+		if treeErr == nil {
+			t.Fatalf("decoding %s: no error", formatNumbers(numbers))
+		}
+		if !reflect.DeepEqual(treeErr, streamErr) {
+			t.Fatalf("decoding %s: buildTree said %q but decodeStream said %q", formatNumbers(numbers), treeErr, streamErr)
+		}
+	}
+}
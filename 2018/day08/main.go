@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -44,49 +46,154 @@ type Node struct {
 	Metadata []uint64
 }
 
-func buildTree(numbers []uint64, startIndex uint64) (node Node, consumedCount uint64) {
-	childCount, metadataCount := numbers[startIndex], numbers[startIndex+1]
-	consumedCount = 2
+// TruncatedError is returned when the input ends before a node is complete.
+type TruncatedError struct {
+	// Node is the node's position in the input, counting the root as 0
+	Node int
+	// Offset is where in the input the missing entries should have started
+	Offset int
+	// What the node was missing: "header" or "metadata"
+	Missing  string
+	Expected uint64
+	// Remaining is how many entries the input had from Offset on
+	Remaining int
+}
 
-	node = Node{
-		Children: make([]Node, 0, childCount),
-		Metadata: make([]uint64, 0, metadataCount),
-	}
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf(
+		"node %d: expected %d %s entries at offset %d but only %d remain",
+		e.Node, e.Expected, e.Missing, e.Offset, e.Remaining,
+	)
+}
+
+// TrailingDataError is returned alongside a complete tree when there are
+// numbers left over after the root node.
+type TrailingDataError struct {
+	Offset int
+	Count  int
+}
+
+func (e *TrailingDataError) Error() string {
+	return fmt.Sprintf("%d trailing entries after the root node, starting at offset %d", e.Count, e.Offset)
+}
 
-	currentIndex := startIndex + consumedCount
+// treeFrame is a node whose header has been read but whose children and
+// metadata are still being read.
+type treeFrame struct {
+	node          Node
+	index         int
+	offset        int
+	childCount    uint64
+	metadataCount uint64
+}
+
+// buildTree decodes the tree using an explicit stack rather than recursion,
+// so deeply nested input can't overflow the goroutine stack. Every read is
+// bounds checked. If numbers continue past the root node, the tree is
+// returned along with a *TrailingDataError.
+func buildTree(numbers []uint64) (Node, error) {
+	offset := 0
+	nodeCount := 0
+	stack := make([]treeFrame, 0)
 
-	for c := uint64(0); c < childCount; c++ {
-		child, consumed := buildTree(numbers, currentIndex)
+	readHeader := func() error {
+		if remaining := len(numbers) - offset; remaining < 2 {
+			return &TruncatedError{Node: nodeCount, Offset: offset, Missing: "header", Expected: 2, Remaining: remaining}
+		}
 
-		node.Children = append(node.Children, child)
+		childCount, metadataCount := numbers[offset], numbers[offset+1]
+		frame := treeFrame{
+			index:         nodeCount,
+			offset:        offset,
+			childCount:    childCount,
+			metadataCount: metadataCount,
+		}
+		offset += 2
+		nodeCount++
+
+		// Counts too big for the rest of the input are reported once the
+		// numbers actually run out, like decodeStream does, but the
+		// allocations are capped so they can't be absurd before then. Each
+		// child needs at least its own header.
+		remaining := uint64(len(numbers) - offset)
+		childCapacity, metadataCapacity := childCount, metadataCount
+		if childCapacity > remaining/2 {
+			childCapacity = remaining / 2
+		}
+		if metadataCapacity > remaining {
+			metadataCapacity = remaining
+		}
 
-		consumedCount += consumed
-		currentIndex += consumed
+		frame.node = Node{
+			Children: make([]Node, 0, childCapacity),
+			Metadata: make([]uint64, 0, metadataCapacity),
+		}
+		stack = append(stack, frame)
+		return nil
 	}
 
-	for m := uint64(0); m < metadataCount; m++ {
-		entry := numbers[currentIndex+m]
-		node.Metadata = append(node.Metadata, entry)
+	if err := readHeader(); err != nil {
+		return Node{}, err
 	}
 
-	consumedCount += metadataCount
+	for {
+		top := &stack[len(stack)-1]
 
-	return
+		if uint64(len(top.node.Children)) < top.childCount {
+			if err := readHeader(); err != nil {
+				return Node{}, err
+			}
+			continue
+		}
+
+		if remaining := len(numbers) - offset; uint64(remaining) < top.metadataCount {
+			return Node{}, &TruncatedError{
+				Node:      top.index,
+				Offset:    offset,
+				Missing:   "metadata",
+				Expected:  top.metadataCount,
+				Remaining: remaining,
+			}
+		}
+		end := offset + int(top.metadataCount)
+		top.node.Metadata = append(top.node.Metadata, numbers[offset:end]...)
+		offset = end
+
+		node := top.node
+		stack = stack[:len(stack)-1]
+		if len(stack) == 0 {
+			if offset < len(numbers) {
+				return node, &TrailingDataError{Offset: offset, Count: len(numbers) - offset}
+			}
+			return node, nil
+		}
+
+		parent := &stack[len(stack)-1]
+		parent.node.Children = append(parent.node.Children, node)
+	}
 }
 
 func sumMetadata(root Node) (metadataSum uint64) {
-	for _, child := range root.Children {
-		metadataSum += sumMetadata(child)
-	}
+	// Walk with an explicit stack, since the tree can be deeper than the
+	// goroutine stack allows recursing
+	stack := []*Node{&root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
 
-	for _, entry := range root.Metadata {
-		metadataSum += entry
+		for _, entry := range node.Metadata {
+			metadataSum += entry
+		}
+		for i := range node.Children {
+			stack = append(stack, &node.Children[i])
+		}
 	}
 
 	return
 }
 
-func calculateValue(node Node) (value uint64) {
+// nodeValue is the value of node given the values of its children.
+func nodeValue(node Node, childValues []uint64) (value uint64) {
 	if len(node.Children) == 0 {
 		for _, entry := range node.Metadata {
 			value += entry
@@ -99,30 +206,108 @@ func calculateValue(node Node) (value uint64) {
 			continue
 		}
 
-		index := int(entry) - 1
-		if index >= len(node.Children) {
+		index := entry - 1
+		if index >= uint64(len(childValues)) {
 			continue
 		}
 
-		child := node.Children[index]
-		value += calculateValue(child)
+		value += childValues[index]
 	}
 
 	return
 }
 
+func calculateValue(root Node) uint64 {
+	type frame struct {
+		node        *Node
+		childValues []uint64
+	}
+	stack := []frame{{node: &root}}
+
+	var value uint64
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if len(top.childValues) < len(top.node.Children) {
+			stack = append(stack, frame{node: &top.node.Children[len(top.childValues)]})
+			continue
+		}
+
+		value = nodeValue(*top.node, top.childValues)
+		stack = stack[:len(stack)-1]
+		if len(stack) > 0 {
+			parent := &stack[len(stack)-1]
+			parent.childValues = append(parent.childValues, value)
+		}
+	}
+
+	return value
+}
+
 func main() {
-	filename := "input.txt"
+	filename := flag.String("input", "input.txt", "license file")
+	strict := flag.Bool("strict", false, "treat trailing data after the root node as an error")
+	draw := flag.Bool("draw", false, "draw the tree with each node's value")
+	generate := flag.Int64("generate", -1, "print a random tree from this seed instead of reading input")
+	stream := flag.Bool("stream", false, "compute the answers in one streaming pass without building the tree")
+	shape := defaultShape
+	flag.IntVar(&shape.MaxDepth, "depth", shape.MaxDepth, "maximum depth of generated trees")
+	flag.IntVar(&shape.MaxChildren, "fanout", shape.MaxChildren, "maximum children per node in generated trees")
+	flag.IntVar(&shape.MaxMetadata, "metadata", shape.MaxMetadata, "maximum metadata entries per node in generated trees")
+	flag.Uint64Var(&shape.MaxValue, "maxvalue", shape.MaxValue, "largest metadata entry in generated trees")
+	flag.Parse()
 
-	numbers, err := readInput(filename)
+	if *generate >= 0 {
+		encoder := newEncodingVisitor(os.Stdout)
+		if err := generateStream(*generate, shape, encoder); err != nil {
+			log.Fatalf("Error generating tree: %s\n", err)
+		}
+		if err := encoder.Flush(); err != nil {
+			log.Fatalf("Error writing tree: %s\n", err)
+		}
+		return
+	}
+	if *stream {
+		f, err := os.Open(*filename)
+		if err != nil {
+			log.Fatalf("Error opening input file %s: %s\n", *filename, err)
+		}
+		defer f.Close()
+
+		var checksum checksumVisitor
+		if err := decodeStream(f, &checksum); err != nil {
+			var trailing *TrailingDataError
+			if *strict || !errors.As(err, &trailing) {
+				log.Fatalf("Error streaming tree: %s\n", err)
+			}
+			log.Printf("Warning: %s\n", err)
+		}
+		fmt.Println("Sum of metadata entries:", checksum.Sum)
+		fmt.Println("Tree value:", checksum.Value)
+		return
+	}
+
+	numbers, err := readInput(*filename)
 	if err != nil {
-		log.Fatalf("Error reading input from %s: %s\n", filename, err)
+		log.Fatalf("Error reading input from %s: %s\n", *filename, err)
 	}
 
-	tree, _ := buildTree(numbers, 0)
+	tree, err := buildTree(numbers)
+	if err != nil {
+		var trailing *TrailingDataError
+		if *strict || !errors.As(err, &trailing) {
+			log.Fatalf("Error building tree: %s\n", err)
+		}
+		log.Printf("Warning: %s\n", err)
+	}
 	sum := sumMetadata(tree)
 	fmt.Println("Sum of metadata entries:", sum)
 
 	value := calculateValue(tree)
 	fmt.Println("Tree value:", value)
+
+	if *draw {
+		if err := drawTree(os.Stdout, tree); err != nil {
+			log.Fatalf("Error drawing tree: %s\n", err)
+		}
+	}
 }
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// NodeHeader describes a node as the stream reaches it.
+type NodeHeader struct {
+	// Index is the node's position in the input, counting the root as 0
+	Index         int
+	Depth         int
+	Offset        int
+	ChildCount    uint64
+	MetadataCount uint64
+}
+
+// Visitor receives a node's header when the stream enters it, then (after all
+// of its children) each of its metadata entries, then a call to Exit. An
+// error returned from any callback stops the stream.
+type Visitor interface {
+	Enter(header NodeHeader) error
+	Metadata(entry uint64) error
+	Exit() error
+}
+
+// decodeStream reads the license format from r one number at a time, calling
+// v as it goes. It holds only the chain of nodes currently open, so memory
+// grows with the depth of the tree rather than its size. Errors match
+// buildTree's, including a *TrailingDataError if numbers follow the root.
+func decodeStream(r io.Reader, v Visitor) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+
+	offset := 0
+	next := func() (uint64, bool, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return 0, false, fmt.Errorf("reading input: %s", err)
+			}
+			return 0, false, nil
+		}
+		value, err := strconv.ParseUint(scanner.Text(), 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("parsing uint64 at offset %d: %s", offset, err)
+		}
+		offset++
+		return value, true, nil
+	}
+
+	type frame struct {
+		header       NodeHeader
+		childrenLeft uint64
+	}
+	stack := make([]frame, 0)
+	nodeCount := 0
+
+	enter := func() error {
+		header := NodeHeader{Index: nodeCount, Depth: len(stack), Offset: offset}
+		counts := make([]uint64, 0, 2)
+		for len(counts) < 2 {
+			value, ok, err := next()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return &TruncatedError{Node: header.Index, Offset: header.Offset, Missing: "header", Expected: 2, Remaining: len(counts)}
+			}
+			counts = append(counts, value)
+		}
+		header.ChildCount, header.MetadataCount = counts[0], counts[1]
+		nodeCount++
+
+		stack = append(stack, frame{header: header, childrenLeft: header.ChildCount})
+		return v.Enter(header)
+	}
+
+	if err := enter(); err != nil {
+		return err
+	}
+
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+
+		if top.childrenLeft > 0 {
+			top.childrenLeft--
+			if err := enter(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		start := offset
+		for m := uint64(0); m < top.header.MetadataCount; m++ {
+			entry, ok, err := next()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return &TruncatedError{
+					Node:      top.header.Index,
+					Offset:    start,
+					Missing:   "metadata",
+					Expected:  top.header.MetadataCount,
+					Remaining: offset - start,
+				}
+			}
+			if err := v.Metadata(entry); err != nil {
+				return err
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		if err := v.Exit(); err != nil {
+			return err
+		}
+	}
+
+	trailingStart := offset
+	for {
+		_, ok, err := next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+	}
+	if offset > trailingStart {
+		return &TrailingDataError{Offset: trailingStart, Count: offset - trailingStart}
+	}
+
+	return nil
+}
+
+// checksumVisitor computes both of the puzzle's answers in a single pass:
+// the sum of all metadata and the value of the root node. Each open node
+// keeps the values of its finished children, since its metadata refers to
+// them and only arrives after they're done.
+type checksumVisitor struct {
+	Sum   uint64
+	Value uint64
+	stack []checksumFrame
+}
+
+type checksumFrame struct {
+	leaf        bool
+	childValues []uint64
+	value       uint64
+}
+
+func (c *checksumVisitor) Enter(header NodeHeader) error {
+	c.stack = append(c.stack, checksumFrame{leaf: header.ChildCount == 0})
+	return nil
+}
+
+func (c *checksumVisitor) Metadata(entry uint64) error {
+	c.Sum += entry
+
+	top := &c.stack[len(c.stack)-1]
+	if top.leaf {
+		top.value += entry
+	} else if entry >= 1 && entry <= uint64(len(top.childValues)) {
+		top.value += top.childValues[entry-1]
+	}
+	return nil
+}
+
+func (c *checksumVisitor) Exit() error {
+	finished := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+
+	if len(c.stack) == 0 {
+		c.Value = finished.value
+	} else {
+		parent := &c.stack[len(c.stack)-1]
+		parent.childValues = append(parent.childValues, finished.value)
+	}
+	return nil
+}
+
+// treeBuilder is a Visitor that assembles the visited nodes into a tree.
+type treeBuilder struct {
+	Root  Node
+	stack []Node
+}
+
+func (b *treeBuilder) Enter(header NodeHeader) error {
+	b.stack = append(b.stack, Node{Children: make([]Node, 0), Metadata: make([]uint64, 0)})
+	return nil
+}
+
+func (b *treeBuilder) Metadata(entry uint64) error {
+	top := &b.stack[len(b.stack)-1]
+	top.Metadata = append(top.Metadata, entry)
+	return nil
+}
+
+func (b *treeBuilder) Exit() error {
+	finished := b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+
+	if len(b.stack) == 0 {
+		b.Root = finished
+	} else {
+		parent := &b.stack[len(b.stack)-1]
+		parent.Children = append(parent.Children, finished)
+	}
+	return nil
+}
+
+// encodingVisitor is a Visitor that writes the visited nodes out in the
+// license format.
+type encodingVisitor struct {
+	w       *bufio.Writer
+	written bool
+}
+
+func newEncodingVisitor(w io.Writer) *encodingVisitor {
+	return &encodingVisitor{w: bufio.NewWriter(w)}
+}
+
+func (e *encodingVisitor) write(n uint64) error {
+	if e.written {
+		if err := e.w.WriteByte(' '); err != nil {
+			return err
+		}
+	}
+	e.written = true
+	_, err := e.w.WriteString(strconv.FormatUint(n, 10))
+	return err
+}
+
+func (e *encodingVisitor) Enter(header NodeHeader) error {
+	if err := e.write(header.ChildCount); err != nil {
+		return err
+	}
+	return e.write(header.MetadataCount)
+}
+
+func (e *encodingVisitor) Metadata(entry uint64) error {
+	return e.write(entry)
+}
+
+func (e *encodingVisitor) Exit() error {
+	return nil
+}
+
+// Flush ends the output with a newline and flushes it.
+func (e *encodingVisitor) Flush() error {
+	if err := e.w.WriteByte('\n'); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// generateStream produces a random tree within shape as a sequence of Visitor
+// calls, so arbitrarily large trees can be written out without holding them
+// in memory. The same seed always gives the same tree.
+func generateStream(seed int64, shape TreeShape, v Visitor) error {
+	if err := shape.validate(); err != nil {
+		return err
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	nodeCount := 0
+	offset := 0
+
+	var generate func(depth int) error
+	generate = func(depth int) error {
+		var childCount uint64
+		if depth < shape.MaxDepth && shape.MaxChildren > 0 {
+			childCount = uint64(r.Intn(shape.MaxChildren + 1))
+		}
+		var metadataCount uint64 = 1
+		if shape.MaxMetadata > 1 {
+			metadataCount += uint64(r.Intn(shape.MaxMetadata))
+		}
+
+		header := NodeHeader{
+			Index:         nodeCount,
+			Depth:         depth,
+			Offset:        offset,
+			ChildCount:    childCount,
+			MetadataCount: metadataCount,
+		}
+		nodeCount++
+		offset += 2
+		if err := v.Enter(header); err != nil {
+			return err
+		}
+		for c := uint64(0); c < childCount; c++ {
+			if err := generate(depth + 1); err != nil {
+				return err
+			}
+		}
+		for m := uint64(0); m < metadataCount; m++ {
+			if err := v.Metadata(randomValue(r, shape.MaxValue)); err != nil {
+				return err
+			}
+		}
+		offset += int(metadataCount)
+		return v.Exit()
+	}
+
+	return generate(0)
+}
+
+// randomValue draws a number from 0 to max inclusive, where max can be
+// anything up to the largest uint64.
+func randomValue(r *rand.Rand, max uint64) uint64 {
+	if max < math.MaxInt64 {
+		return uint64(r.Int63n(int64(max) + 1))
+	}
+	if max == math.MaxUint64 {
+		return r.Uint64()
+	}
+	// At least half of all uint64s are in range, so this soon finishes
+	for {
+		if value := r.Uint64(); value <= max {
+			return value
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Circle is the ring of placed marbles, stored as a doubly linked list in two
+// flat slices indexed by marble value.
+type Circle struct {
+	clockwise        []int
+	counterClockwise []int
+	// start is where Marbles begins: marble 0, or whichever marble has taken
+	// its place if it's been removed
+	start   int
+	Current int
+}
+
+// newCircle makes a circle holding just marble 0, with room for marbles up
+// to lastMarbleValue.
+func newCircle(lastMarbleValue int) *Circle {
+	return &Circle{
+		clockwise:        make([]int, lastMarbleValue+1),
+		counterClockwise: make([]int, lastMarbleValue+1),
+	}
+}
+
+// insert places marble immediately clockwise of the marble offset places
+// clockwise of the current one, and makes it current.
+func (c *Circle) insert(marble, offset int) {
+	before := c.Current
+	for i := 0; i < offset; i++ {
+		before = c.clockwise[before]
+	}
+	after := c.clockwise[before]
+	c.clockwise[before] = marble
+	c.counterClockwise[marble] = before
+	c.clockwise[marble] = after
+	c.counterClockwise[after] = marble
+	c.Current = marble
+}
+
+// remove takes out the marble offset places counter-clockwise of the current
+// one and returns it. The marble clockwise of it becomes current.
+func (c *Circle) remove(offset int) int {
+	removed := c.Current
+	for i := 0; i < offset; i++ {
+		removed = c.counterClockwise[removed]
+	}
+	before, after := c.counterClockwise[removed], c.clockwise[removed]
+	c.clockwise[before] = after
+	c.counterClockwise[after] = before
+	c.Current = after
+	if removed == c.start {
+		c.start = after
+	}
+	return removed
+}
+
+// Marbles lists the marbles clockwise, starting from marble 0.
+func (c *Circle) Marbles() []int {
+	marbles := []int{c.start}
+	for m := c.clockwise[c.start]; m != c.start; m = c.clockwise[m] {
+		marbles = append(marbles, m)
+	}
+	return marbles
+}
+
+// Event describes one turn of the game.
+type Event struct {
+	// Player is 1-indexed
+	Player int
+	Marble int
+	// Scored is true if the player kept Marble and removed Removed, rather
+	// than placing Marble in the circle
+	Scored  bool
+	Removed int
+	// Score is the player's total after this turn
+	Score int
+	// Circle is the state after this turn. It's only valid during the call
+	// the event is passed to.
+	Circle *Circle
+}
+
+// traceTurns returns an observer for play that prints the circle after every
+// turn, like the puzzle description. Each line is O(marbles) long, so it's
+// only practical for small games.
+func traceTurns(w io.Writer) func(Event) {
+	return func(e Event) {
+		fmt.Fprintf(w, "[%d]%s\n", e.Player, formatCircle(e.Circle))
+	}
+}
+
+// writeTrace plays game under rules, printing the starting circle and then
+// the circle after every turn.
+func writeTrace(w io.Writer, game Game, rules Rules) ([]int, error) {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "[-]%s\n", formatCircle(newCircle(0)))
+	scores, err := play(game, rules, traceTurns(out))
+	if err != nil {
+		return nil, err
+	}
+	return scores, out.Flush()
+}
+
+// formatCircle lays marbles out in three-character columns, with the current
+// marble in parentheses squeezed into its neighbors' padding.
+func formatCircle(c *Circle) string {
+	var b strings.Builder
+	closing := false
+	for _, m := range c.Marbles() {
+		switch {
+		case m == c.Current:
+			fmt.Fprintf(&b, "%3s", fmt.Sprintf("(%d", m))
+			closing = true
+		case closing:
+			fmt.Fprintf(&b, ")%2d", m)
+			closing = false
+		default:
+			fmt.Fprintf(&b, "%3d", m)
+		}
+	}
+	if closing {
+		b.WriteByte(')')
+	}
+	return b.String()
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"bufio"
-	"container/ring"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -49,43 +49,81 @@ func readInput(filename string) (Game, error) {
 	return game, nil
 }
 
-func play(game Game) map[int]int {
-	scores := make(map[int]int)
-	// Players are 1-indexed
-	for p := 1; p <= game.PlayerCount; p++ {
-		scores[p] = 0
+// Rules are the numbers that govern how marbles are placed and scored.
+type Rules struct {
+	// A marble whose value is a multiple of SpecialMultiple is kept by the
+	// player instead of being placed
+	SpecialMultiple int
+	// RemovalOffset is how many marbles counter-clockwise of the current one
+	// the marble removed on a special turn is
+	RemovalOffset int
+	// InsertionOffset is how many marbles clockwise of the current one a new
+	// marble goes immediately clockwise of
+	InsertionOffset int
+}
+
+var puzzleRules = Rules{SpecialMultiple: 23, RemovalOffset: 7, InsertionOffset: 1}
+
+func (r Rules) validate() error {
+	// Every special turn follows at least one placement, so the circle never
+	// has fewer than two marbles when one is removed
+	if r.SpecialMultiple < 2 {
+		return fmt.Errorf("special multiple must be at least 2, got %d", r.SpecialMultiple)
+	}
+	if r.RemovalOffset < 0 {
+		return fmt.Errorf("removal offset must not be negative, got %d", r.RemovalOffset)
 	}
+	if r.InsertionOffset < 0 {
+		return fmt.Errorf("insertion offset must not be negative, got %d", r.InsertionOffset)
+	}
+	return nil
+}
 
-	zero := ring.New(1)
-	zero.Value = 0
+// play runs the game under rules and returns each player's final score,
+// indexed from 0 for player 1. The circle lives in flat slices, so placing or
+// removing a marble never allocates. If observe isn't nil, it's called after
+// every turn.
+func play(game Game, rules Rules, observe func(Event)) ([]int, error) {
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
 
-	current := zero
+	scores := make([]int, game.PlayerCount)
+	circle := newCircle(game.LastMarbleValue)
 
 	for nextMarbleValue := 1; nextMarbleValue <= game.LastMarbleValue; nextMarbleValue++ {
-		player := ((nextMarbleValue - 1) % game.PlayerCount) + 1
+		player := (nextMarbleValue - 1) % game.PlayerCount
 
-		if nextMarbleValue%23 == 0 {
-			removed := current.Move(-8).Unlink(1)
-			current = current.Move(-6)
-
-			scores[player] += nextMarbleValue
-			scores[player] += removed.Value.(int)
+		scored := nextMarbleValue%rules.SpecialMultiple == 0
+		removed := 0
+		if scored {
+			removed = circle.remove(rules.RemovalOffset)
+			scores[player] += nextMarbleValue + removed
 		} else {
-			nextMarble := ring.New(1)
-			nextMarble.Value = nextMarbleValue
+			circle.insert(nextMarbleValue, rules.InsertionOffset)
+		}
 
-			current.Next().Link(nextMarble)
-			current = nextMarble
+		if observe != nil {
+			observe(Event{
+				Player:  player + 1,
+				Marble:  nextMarbleValue,
+				Scored:  scored,
+				Removed: removed,
+				Score:   scores[player],
+				Circle:  circle,
+			})
 		}
 	}
 
-	return scores
+	return scores, nil
 }
 
-func getWinner(scores map[int]int) (winner, highScore int) {
-	for player, score := range scores {
-		if score > highScore {
-			winner = player
+// getWinner returns the (1-indexed) player with the highest score. Ties go to
+// the lowest-numbered player, as in a RaceReport.
+func getWinner(scores []int) (winner, highScore int) {
+	for i, score := range scores {
+		if i == 0 || score > highScore {
+			winner = i + 1
 			highScore = score
 		}
 	}
@@ -93,19 +131,67 @@ func getWinner(scores map[int]int) (winner, highScore int) {
 }
 
 func main() {
-	filename := "input.txt"
-
-	game, err := readInput(filename)
+	filename := flag.String("input", "input.txt", "game input file")
+	multiple := flag.Int("multiple", puzzleRules.SpecialMultiple, "marbles with values divisible by this are scored")
+	removal := flag.Int("removal", puzzleRules.RemovalOffset, "how far counter-clockwise the marble removed when scoring is")
+	insertion := flag.Int("insertion", puzzleRules.InsertionOffset, "how far clockwise new marbles are placed, less one")
+	trace := flag.Bool("trace", false, "print the circle after every turn of the input game (small games only)")
+	standings := flag.Int("standings", 0, "print the top N players in the input game and how the lead changed")
+	curves := flag.String("curves", "", "write each player's score curve in the input game to this CSV file")
+	compare := flag.String("compare", "", "comma-separated player counts to compare using the input's last marble")
+	flag.Parse()
+
+	game, err := readInput(*filename)
 	if err != nil {
-		log.Fatalf("Error reading input from %s: %s\n", filename, err)
+		log.Fatalf("Error reading input from %s: %s\n", *filename, err)
 	}
 
-	scores := play(game)
+	rules := Rules{SpecialMultiple: *multiple, RemovalOffset: *removal, InsertionOffset: *insertion}
+
+	var scores []int
+	if *trace {
+		scores, err = writeTrace(os.Stdout, game, rules)
+	} else {
+		scores, err = play(game, rules, nil)
+	}
+	if err != nil {
+		log.Fatalf("Error playing %+v: %s\n", game, err)
+	}
 	winner, highScore := getWinner(scores)
 	fmt.Printf("Winner of %+v is player %d with %d points\n", game, winner, highScore)
 
+	if *standings > 0 || *curves != "" {
+		var report RaceReport
+		if *curves != "" {
+			report, err = writeCurves(*curves, game, rules)
+		} else {
+			report, err = race(game, rules, nil)
+		}
+		if err != nil {
+			log.Fatalf("Error reporting on %+v: %s\n", game, err)
+		}
+		if *standings > 0 {
+			if err := printStandings(os.Stdout, report, *standings); err != nil {
+				log.Fatalf("Error printing standings: %s\n", err)
+			}
+		}
+	}
+
+	if *compare != "" {
+		counts, err := parsePlayerCounts(*compare)
+		if err != nil {
+			log.Fatalf("Error parsing -compare: %s\n", err)
+		}
+		if err := compareRaces(os.Stdout, game.LastMarbleValue, counts, rules); err != nil {
+			log.Fatalf("Error comparing games: %s\n", err)
+		}
+	}
+
 	bigGame := Game{PlayerCount: game.PlayerCount, LastMarbleValue: game.LastMarbleValue * 100}
-	scores = play(bigGame)
+	scores, err = play(bigGame, rules, nil)
+	if err != nil {
+		log.Fatalf("Error playing %+v: %s\n", bigGame, err)
+	}
 	winner, highScore = getWinner(scores)
 	fmt.Printf("Winner of %+v is player %d with %d points\n", bigGame, winner, highScore)
 }
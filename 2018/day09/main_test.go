@@ -0,0 +1,109 @@
+package main
+
+import (
+	"container/ring"
+	"fmt"
+	"testing"
+)
+
+// playRing is the original container/ring implementation of play, kept to
+// check and benchmark against. Its scores map is keyed by player number.
+func playRing(game Game) map[int]int {
+	scores := make(map[int]int)
+	// Players are 1-indexed
+	for p := 1; p <= game.PlayerCount; p++ {
+		scores[p] = 0
+	}
+
+	zero := ring.New(1)
+	zero.Value = 0
+
+	current := zero
+
+	for nextMarbleValue := 1; nextMarbleValue <= game.LastMarbleValue; nextMarbleValue++ {
+		player := ((nextMarbleValue - 1) % game.PlayerCount) + 1
+
+		if nextMarbleValue%23 == 0 {
+			removed := current.Move(-8).Unlink(1)
+			current = current.Move(-6)
+
+			scores[player] += nextMarbleValue
+			scores[player] += removed.Value.(int)
+		} else {
+			nextMarble := ring.New(1)
+			nextMarble.Value = nextMarbleValue
+
+			current.Next().Link(nextMarble)
+			current = nextMarble
+		}
+	}
+
+	return scores
+}
+
+// exampleGames are the games from the puzzle description, with their high
+// scores.
+var exampleGames = []struct {
+	game      Game
+	highScore int
+}{
+	{game: Game{PlayerCount: 9, LastMarbleValue: 25}, highScore: 32},
+	{game: Game{PlayerCount: 10, LastMarbleValue: 1618}, highScore: 8317},
+	{game: Game{PlayerCount: 13, LastMarbleValue: 7999}, highScore: 146373},
+	{game: Game{PlayerCount: 17, LastMarbleValue: 1104}, highScore: 2764},
+	{game: Game{PlayerCount: 21, LastMarbleValue: 6111}, highScore: 54718},
+	{game: Game{PlayerCount: 30, LastMarbleValue: 5807}, highScore: 37305},
+}
+
+func TestPlay(t *testing.T) {
+	for _, example := range exampleGames {
+		scores, err := play(example.game, puzzleRules, nil)
+		if err != nil {
+			t.Fatalf("%+v: %s", example.game, err)
+		}
+		if _, highScore := getWinner(scores); highScore != example.highScore {
+			t.Errorf("%+v: high score is %d, want %d", example.game, highScore, example.highScore)
+		}
+
+		ringHighScore := 0
+		for _, score := range playRing(example.game) {
+			if score > ringHighScore {
+				ringHighScore = score
+			}
+		}
+		if ringHighScore != example.highScore {
+			t.Errorf("%+v: ring high score is %d, want %d", example.game, ringHighScore, example.highScore)
+		}
+	}
+}
+
+// benchmarkGames are a game the size of the puzzle input, and one 100 times
+// bigger like the second part.
+var benchmarkGames = []Game{
+	{PlayerCount: 470, LastMarbleValue: 72170},
+	{PlayerCount: 470, LastMarbleValue: 7217000},
+}
+
+func BenchmarkPlayRing(b *testing.B) {
+	for _, game := range benchmarkGames {
+		game := game
+		b.Run(fmt.Sprint(game.LastMarbleValue), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				playRing(game)
+			}
+		})
+	}
+}
+
+func BenchmarkPlay(b *testing.B) {
+	for _, game := range benchmarkGames {
+		game := game
+		b.Run(fmt.Sprint(game.LastMarbleValue), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				play(game, puzzleRules, nil)
+			}
+		})
+	}
+}
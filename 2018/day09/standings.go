@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Standing is one player's place at the end of a game.
+type Standing struct {
+	// Rank is 1 for the highest score. Tied players share a rank and the next
+	// rank skips past them, so two players tied for first are both 1 and the
+	// player after them is 3.
+	Rank   int
+	Player int
+	Score  int
+}
+
+// RaceReport describes how a game was won.
+type RaceReport struct {
+	Game Game
+	// Standings are best first, with tied players in player order
+	Standings []Standing
+	// LeadChanges counts the times the lead passed to a different player.
+	// Only a player strictly ahead of everyone else has the lead, so a tie
+	// followed by the same player pulling ahead again isn't a change.
+	LeadChanges int
+	// WinnerLeadSince is the turn from which the winner stayed strictly ahead
+	// for the rest of the game, or 0 if the game ended in a tie for first
+	WinnerLeadSince int
+}
+
+// Winner is the first-ranked player, the lowest-numbered one in a tie.
+func (r RaceReport) Winner() Standing {
+	return r.Standings[0]
+}
+
+// raceTracker follows the scores over a game as an observer for play.
+type raceTracker struct {
+	scores []int
+	// top is the high score so far
+	top int
+	// leader is the player strictly ahead of everyone else, or 0 during a tie
+	leader      int
+	lastLeader  int
+	leadSince   int
+	leadChanges int
+
+	curves *csv.Writer
+	err    error
+}
+
+// newRaceTracker makes a tracker for game. If curves isn't nil, every score
+// change is also written to it as a CSV row of turn, player and new score.
+func newRaceTracker(game Game, curves io.Writer) *raceTracker {
+	t := &raceTracker{scores: make([]int, game.PlayerCount)}
+	if curves != nil {
+		t.curves = csv.NewWriter(curves)
+		t.err = t.curves.Write([]string{"turn", "player", "score"})
+	}
+	return t
+}
+
+func (t *raceTracker) observe(e Event) {
+	// Scores only change on scoring turns, and only go up
+	if !e.Scored {
+		return
+	}
+	t.scores[e.Player-1] = e.Score
+
+	switch {
+	case e.Score > t.top:
+		t.top = e.Score
+		if t.leader != e.Player {
+			if t.lastLeader != 0 && t.lastLeader != e.Player {
+				t.leadChanges++
+			}
+			t.leader, t.lastLeader, t.leadSince = e.Player, e.Player, e.Marble
+		}
+	case e.Score == t.top:
+		t.leader = 0
+	}
+
+	if t.curves != nil && t.err == nil {
+		t.err = t.curves.Write([]string{strconv.Itoa(e.Marble), strconv.Itoa(e.Player), strconv.Itoa(e.Score)})
+	}
+}
+
+// flush finishes writing the score curves, if there are any.
+func (t *raceTracker) flush() error {
+	if t.curves == nil || t.err != nil {
+		return t.err
+	}
+	t.curves.Flush()
+	return t.curves.Error()
+}
+
+func (t *raceTracker) report(game Game) RaceReport {
+	r := RaceReport{Game: game, Standings: make([]Standing, len(t.scores)), LeadChanges: t.leadChanges}
+	for i, score := range t.scores {
+		r.Standings[i] = Standing{Player: i + 1, Score: score}
+	}
+	sort.SliceStable(r.Standings, func(i, j int) bool {
+		return r.Standings[i].Score > r.Standings[j].Score
+	})
+	for i := range r.Standings {
+		if i > 0 && r.Standings[i].Score == r.Standings[i-1].Score {
+			r.Standings[i].Rank = r.Standings[i-1].Rank
+		} else {
+			r.Standings[i].Rank = i + 1
+		}
+	}
+
+	if len(r.Standings) > 0 && t.leader == r.Standings[0].Player {
+		r.WinnerLeadSince = t.leadSince
+	}
+
+	return r
+}
+
+// race plays game under rules and reports on it. If curves isn't nil, each
+// player's score curve is written to it as CSV.
+func race(game Game, rules Rules, curves io.Writer) (RaceReport, error) {
+	t := newRaceTracker(game, curves)
+	if _, err := play(game, rules, t.observe); err != nil {
+		return RaceReport{}, err
+	}
+	if err := t.flush(); err != nil {
+		return RaceReport{}, fmt.Errorf("writing score curves: %s", err)
+	}
+	return t.report(game), nil
+}
+
+// writeCurves plays game under rules, writing the score curves to filename.
+func writeCurves(filename string, game Game, rules Rules) (RaceReport, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return RaceReport{}, fmt.Errorf("creating file %s: %s", filename, err)
+	}
+	defer f.Close()
+
+	report, err := race(game, rules, f)
+	if err != nil {
+		return RaceReport{}, err
+	}
+	return report, f.Close()
+}
+
+// printStandings prints the top places in report, and how the lead went.
+func printStandings(w io.Writer, report RaceReport, top int) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, "Standings for %+v\n", report.Game)
+	fmt.Fprintln(out, "Rank  Player       Score")
+	for i, s := range report.Standings {
+		if i == top {
+			fmt.Fprintf(out, "... %d more\n", len(report.Standings)-top)
+			break
+		}
+		fmt.Fprintf(out, "%4d  %6d  %10d\n", s.Rank, s.Player, s.Score)
+	}
+
+	fmt.Fprintf(out, "Lead changes: %d\n", report.LeadChanges)
+	if report.WinnerLeadSince > 0 {
+		fmt.Fprintf(out, "Player %d led for good from turn %d\n", report.Winner().Player, report.WinnerLeadSince)
+	} else {
+		fmt.Fprintln(out, "The game ended in a tie for first")
+	}
+
+	return out.Flush()
+}
+
+// parsePlayerCounts parses a comma-separated list of player counts.
+func parsePlayerCounts(s string) ([]int, error) {
+	counts := make([]int, 0)
+	for _, field := range strings.Split(s, ",") {
+		count, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("parsing player count %q: %s", field, err)
+		}
+		if count < 1 {
+			return nil, fmt.Errorf("player count must be at least 1, got %d", count)
+		}
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
+
+// compareRaces plays the game with each number of players in counts, and
+// prints a line summarizing each.
+func compareRaces(w io.Writer, lastMarbleValue int, counts []int, rules Rules) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, "Players  Winner       Score     Margin  Lead changes  Led from turn")
+	for _, count := range counts {
+		report, err := race(Game{PlayerCount: count, LastMarbleValue: lastMarbleValue}, rules, nil)
+		if err != nil {
+			return err
+		}
+
+		winner := report.Winner()
+		margin := winner.Score
+		if len(report.Standings) > 1 {
+			margin -= report.Standings[1].Score
+		}
+		fmt.Fprintf(out, "%7d  %6d  %10d  %9d  %12d  %13d\n",
+			count, winner.Player, winner.Score, margin, report.LeadChanges, report.WinnerLeadSince)
+	}
+
+	return out.Flush()
+}
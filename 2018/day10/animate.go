@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Animation is a run of frames, one per second, around the moment the
+// message appears. Every frame shows the same window of the sky: the message
+// plus a margin, so it's drawn at a legible size while the points rush in
+// from outside.
+type Animation struct {
+	Points []Point
+	// Start and End are the first and last seconds shown, and Message the
+	// one the message appears at
+	Start, End, Message int64
+	Min, Max            point
+	// Scale is the size of each point in pixels
+	Scale int
+	Delay time.Duration
+}
+
+// targetWidth is about how wide the automatically scaled images are.
+const targetWidth = 640
+
+// newAnimation frames the seconds from window before messageT to window
+// after. A scale of 0 picks one that makes the images about targetWidth
+// pixels wide.
+func newAnimation(points []Point, messageT, window int64, scale int, delay time.Duration) (Animation, error) {
+	if window < 0 {
+		return Animation{}, fmt.Errorf("window must not be negative, got %d", window)
+	}
+	if scale < 0 {
+		return Animation{}, fmt.Errorf("scale must not be negative, got %d", scale)
+	}
+
+	min, max := boundsAt(points, messageT)
+	margin := (max.Y - min.Y + 1) / 2
+	if margin < 2 {
+		margin = 2
+	}
+	min.X, min.Y = min.X-margin, min.Y-margin
+	max.X, max.Y = max.X+margin, max.Y+margin
+
+	if scale == 0 {
+		scale = int(targetWidth / (max.X - min.X + 1))
+		if scale < 1 {
+			scale = 1
+		}
+	}
+
+	return Animation{
+		Points:  points,
+		Start:   messageT - window,
+		End:     messageT + window,
+		Message: messageT,
+		Min:     min,
+		Max:     max,
+		Scale:   scale,
+		Delay:   delay,
+	}, nil
+}
+
+var animationPalette = color.Palette{
+	color.RGBA{R: 0x0f, G: 0x0f, B: 0x23, A: 0xff},
+	color.RGBA{R: 0xcc, G: 0xcc, B: 0xcc, A: 0xff},
+	// The message frame is drawn in gold
+	color.RGBA{R: 0xff, G: 0xff, B: 0x66, A: 0xff},
+}
+
+// frame draws the points at time t. Points outside the window are left out.
+func (a Animation) frame(t int64) *image.Paletted {
+	width := int(a.Max.X-a.Min.X+1) * a.Scale
+	height := int(a.Max.Y-a.Min.Y+1) * a.Scale
+	img := image.NewPaletted(image.Rect(0, 0, width, height), animationPalette)
+
+	var ink uint8 = 1
+	if t == a.Message {
+		ink = 2
+	}
+
+	for p := range plot(a.Points, t) {
+		if p.X < a.Min.X || p.X > a.Max.X || p.Y < a.Min.Y || p.Y > a.Max.Y {
+			continue
+		}
+		x0 := int(p.X-a.Min.X) * a.Scale
+		y0 := int(p.Y-a.Min.Y) * a.Scale
+		for y := y0; y < y0+a.Scale; y++ {
+			for x := x0; x < x0+a.Scale; x++ {
+				img.SetColorIndex(x, y, ink)
+			}
+		}
+	}
+
+	return img
+}
+
+// writeGIF saves the animation as a looping GIF, pausing on the message.
+func (a Animation) writeGIF(filename string) error {
+	anim := &gif.GIF{}
+	for t := a.Start; t <= a.End; t++ {
+		delay := a.Delay
+		if t == a.Message {
+			delay *= 10
+		}
+		anim.Image = append(anim.Image, a.frame(t))
+		// GIF delays are in hundredths of a second
+		anim.Delay = append(anim.Delay, int(delay/(10*time.Millisecond)))
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("creating GIF file %s: %s", filename, err)
+	}
+	defer f.Close()
+
+	if err := gif.EncodeAll(f, anim); err != nil {
+		return fmt.Errorf("encoding GIF: %s", err)
+	}
+
+	return f.Close()
+}
+
+// writePNGs saves each frame as a PNG in dir, named by its index so they
+// sort in order.
+func (a Animation) writePNGs(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %s", dir, err)
+	}
+
+	for t := a.Start; t <= a.End; t++ {
+		filename := filepath.Join(dir, fmt.Sprintf("frame-%04d.png", t-a.Start))
+		if err := writePNG(filename, a.frame(t)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writePNG(filename string, img image.Image) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("creating image file %s: %s", filename, err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("encoding PNG: %s", err)
+	}
+
+	return f.Close()
+}
+
+// replay plays the animation in a terminal, redrawing each frame in place
+// with ANSI escape codes.
+func (a Animation) replay(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	// Hide the cursor and clear the screen
+	fmt.Fprint(out, "\x1b[?25l\x1b[2J")
+	for t := a.Start; t <= a.End; t++ {
+		// Move to the top left and draw over the last frame
+		fmt.Fprint(out, "\x1b[H")
+		fmt.Fprintf(out, "After %d seconds:\x1b[K\n", t)
+		drawWindow(out, plot(a.Points, t), a.Min, a.Max)
+		if err := out.Flush(); err != nil {
+			return err
+		}
+
+		delay := a.Delay
+		if t == a.Message {
+			delay *= 10
+		}
+		time.Sleep(delay)
+	}
+	fmt.Fprint(out, "\x1b[?25h")
+
+	return out.Flush()
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"errors"
+	"math"
+)
+
+// errNoConvergence means every point moves with the same velocity, so the
+// arrangement never changes and there's no best moment to look at it.
+var errNoConvergence = errors.New("all points move together, so they never converge")
+
+// boundsAt finds the bounding box of the points at time t without plotting
+// them.
+func boundsAt(points []Point, t int64) (min, max point) {
+	min.X, min.Y = math.MaxInt64, math.MaxInt64
+	max.X, max.Y = math.MinInt64, math.MinInt64
+
+	for _, p := range points {
+		x := p.Position.X + p.Velocity.X*t
+		y := p.Position.Y + p.Velocity.Y*t
+		if x < min.X {
+			min.X = x
+		}
+		if x > max.X {
+			max.X = x
+		}
+		if y < min.Y {
+			min.Y = y
+		}
+		if y > max.Y {
+			max.Y = y
+		}
+	}
+
+	return
+}
+
+// spread measures how far apart the points are: the area of their bounding
+// box, then its width plus height to break ties. The area alone is zero
+// whenever the points are in a line, but the sides aren't. Both are float64s
+// since far from convergence they can be too big for int64s.
+type spread struct {
+	area, size float64
+}
+
+func (s spread) less(other spread) bool {
+	if s.area != other.area {
+		return s.area < other.area
+	}
+	return s.size < other.size
+}
+
+// spreadAt measures the spread of the points at time t.
+func spreadAt(points []Point, t int64) spread {
+	min, max := boundsAt(points, t)
+	width, height := float64(max.X-min.X), float64(max.Y-min.Y)
+	return spread{area: width * height, size: width + height}
+}
+
+// axisEstimate guesses when points converge along one axis, from the slowest
+// and fastest points: they start furthest apart along the direction they're
+// closing in, so they meet about when everything else does. Points sharing an
+// extreme velocity are averaged. ok is false if every point has the same
+// velocity along the axis, which says nothing about when they converge.
+func axisEstimate(positions, velocities []int64) (t float64, ok bool) {
+	minV, maxV := velocities[0], velocities[0]
+	for _, v := range velocities[1:] {
+		if v < minV {
+			minV = v
+		}
+		if v > maxV {
+			maxV = v
+		}
+	}
+	if minV == maxV {
+		return 0, false
+	}
+
+	var slowSum, fastSum float64
+	var slowCount, fastCount int
+	for i, v := range velocities {
+		switch v {
+		case minV:
+			slowSum += float64(positions[i])
+			slowCount++
+		case maxV:
+			fastSum += float64(positions[i])
+			fastCount++
+		}
+	}
+	slow, fast := slowSum/float64(slowCount), fastSum/float64(fastCount)
+
+	return (slow - fast) / float64(maxV-minV), true
+}
+
+// estimateConvergence guesses when the points are closest together, averaging
+// the estimates for each axis that has one.
+func estimateConvergence(points []Point) (float64, error) {
+	xs, vxs := make([]int64, len(points)), make([]int64, len(points))
+	ys, vys := make([]int64, len(points)), make([]int64, len(points))
+	for i, p := range points {
+		xs[i], vxs[i] = p.Position.X, p.Velocity.X
+		ys[i], vys[i] = p.Position.Y, p.Velocity.Y
+	}
+
+	var sum float64
+	count := 0
+	if t, ok := axisEstimate(xs, vxs); ok {
+		sum += t
+		count++
+	}
+	if t, ok := axisEstimate(ys, vys); ok {
+		sum += t
+		count++
+	}
+	if count == 0 {
+		return 0, errNoConvergence
+	}
+
+	return sum / float64(count), nil
+}
+
+// timeLimit is the furthest from 0 that t can be without any point's position
+// risking overflow.
+func timeLimit(points []Point) int64 {
+	var maxPosition, maxVelocity int64 = 1, 1
+	for _, p := range points {
+		for _, c := range []int64{p.Position.X, p.Position.Y} {
+			if c < 0 {
+				c = -c
+			}
+			if c > maxPosition {
+				maxPosition = c
+			}
+		}
+		for _, c := range []int64{p.Velocity.X, p.Velocity.Y} {
+			if c < 0 {
+				c = -c
+			}
+			if c > maxVelocity {
+				maxVelocity = c
+			}
+		}
+	}
+	// Leave room to subtract one coordinate from another in spreadAt
+	return (math.MaxInt64/4 - maxPosition) / maxVelocity
+}
+
+// findConvergence finds the time at which the points' bounding box is
+// smallest, which may be negative. It starts from estimateConvergence and
+// searches around it with steps that halve whenever neither direction
+// improves, so each step only computes a bounding box.
+//
+// The search assumes the spread has a single minimum. That's true of the
+// width plus height, but not always of the area: when the points line up
+// horizontally and vertically at different times, the area can have a
+// minimum at each and the search may settle in the wrong one.
+func findConvergence(points []Point) (int64, error) {
+	if len(points) == 0 {
+		return 0, errors.New("no points")
+	}
+
+	estimate, err := estimateConvergence(points)
+	if err != nil {
+		return 0, err
+	}
+
+	limit := timeLimit(points)
+	clamp := func(t int64) int64 {
+		if t > limit {
+			return limit
+		}
+		if t < -limit {
+			return -limit
+		}
+		return t
+	}
+
+	var t int64
+	switch {
+	case estimate >= float64(limit):
+		t = limit
+	case estimate <= float64(-limit):
+		t = -limit
+	default:
+		t = int64(math.Round(estimate))
+	}
+	best := spreadAt(points, t)
+
+	// The estimate is usually within a few seconds, but start wide enough
+	// to recover if the extremes were misleading
+	step := int64(1)
+	for step < limit/2 && step < 1<<20 {
+		step *= 2
+	}
+
+	for step >= 1 {
+		if next := clamp(t + step); next != t {
+			if spread := spreadAt(points, next); spread.less(best) {
+				t, best = next, spread
+				continue
+			}
+		}
+		if next := clamp(t - step); next != t {
+			if spread := spreadAt(points, next); spread.less(best) {
+				t, best = next, spread
+				continue
+			}
+		}
+		step /= 2
+	}
+
+	return earliestWithSpread(points, t, best, limit), nil
+}
+
+// earliestWithSpread finds the start of the run of times up to t whose spread
+// is best, so ties go to the earliest time. Steps to the left double until
+// one leaves the run, then a binary search finds its edge, so even a run
+// stretching back to -limit only takes a few bounding boxes.
+func earliestWithSpread(points []Point, t int64, best spread, limit int64) int64 {
+	within := func(t int64) bool {
+		return spreadAt(points, t) == best
+	}
+
+	// within(t) holds throughout, and within(outside) doesn't, unless
+	// outside is -limit
+	outside := t
+	for step := int64(1); ; step *= 2 {
+		outside = -limit
+		if step < t+limit {
+			outside = t - step
+		}
+		if !within(outside) {
+			break
+		}
+		t = outside
+		if t == -limit {
+			return t
+		}
+	}
+
+	for t-outside > 1 {
+		mid := outside + (t-outside)/2
+		if within(mid) {
+			t = mid
+		} else {
+			outside = mid
+		}
+	}
+
+	return t
+}
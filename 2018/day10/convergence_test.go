@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// examplePoints are the points from the puzzle description, which spell HI
+// after 3 seconds.
+const examplePoints = `
+position=< 9,  1> velocity=< 0,  2>
+position=< 7,  0> velocity=<-1,  0>
+position=< 3, -2> velocity=<-1,  1>
+position=< 6, 10> velocity=<-2, -1>
+position=< 2, -4> velocity=< 2,  2>
+position=<-6, 10> velocity=< 2, -2>
+position=< 1,  8> velocity=< 1, -1>
+position=< 1,  7> velocity=< 1,  0>
+position=<-3, 11> velocity=< 1, -2>
+position=< 7,  6> velocity=<-1, -1>
+position=<-2,  3> velocity=< 1,  0>
+position=<-4,  3> velocity=< 2,  0>
+position=<10, -3> velocity=<-1,  1>
+position=< 5, 11> velocity=< 1, -2>
+position=< 4,  7> velocity=< 0, -1>
+position=< 8, -2> velocity=< 0,  1>
+position=<15,  0> velocity=<-2,  0>
+position=< 1,  6> velocity=< 1,  0>
+position=< 8,  9> velocity=< 0, -1>
+position=< 3,  3> velocity=<-1,  1>
+position=< 0,  5> velocity=< 0, -1>
+position=<-2,  2> velocity=< 2,  0>
+position=< 5, -2> velocity=< 1,  2>
+position=< 1,  4> velocity=< 2,  1>
+position=<-2,  7> velocity=< 2, -2>
+position=< 3,  6> velocity=<-1, -1>
+position=< 5,  0> velocity=< 1,  0>
+position=<-6,  0> velocity=< 2,  0>
+position=< 5,  9> velocity=< 1, -2>
+position=<14,  7> velocity=<-2,  0>
+position=<-3,  6> velocity=< 2, -1>
+`
+
+func parsePoints(t *testing.T, text string) []Point {
+	points := make([]Point, 0)
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		var p Point
+		if _, err := fmt.Sscanf(line, "position=<%d, %d> velocity=<%d, %d>",
+			&p.Position.X, &p.Position.Y, &p.Velocity.X, &p.Velocity.Y); err != nil {
+			t.Fatalf("parsing %q: %s", line, err)
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// shiftTime moves every point along its path so that the arrangement at time
+// from happens at time to instead.
+func shiftTime(points []Point, from, to int64) []Point {
+	shifted := make([]Point, len(points))
+	for i, p := range points {
+		shifted[i] = p
+		shifted[i].Position.X += p.Velocity.X * (from - to)
+		shifted[i].Position.Y += p.Velocity.Y * (from - to)
+	}
+	return shifted
+}
+
+func TestFindConvergence(t *testing.T) {
+	example := parsePoints(t, examplePoints)
+
+	tests := []struct {
+		name   string
+		points []Point
+		want   int64
+	}{
+		{name: "example", points: example, want: 3},
+		{name: "negative", points: shiftTime(example, 3, -1000), want: -1000},
+		{name: "far future", points: shiftTime(example, 3, 1000000000000), want: 1000000000000},
+		{name: "far past", points: shiftTime(example, 3, -1000000000000), want: -1000000000000},
+		{
+			// The bounding box has no area at any time, but the points
+			// still meet
+			name: "collinear",
+			points: parsePoints(t, `
+position=< 0, 0> velocity=< 1, 0>
+position=<10, 0> velocity=<-1, 0>`),
+			want: 5,
+		},
+		{
+			name: "collinear vertically",
+			points: parsePoints(t, `
+position=< 3, -40> velocity=< 0,  4>
+position=< 3,  40> velocity=< 0, -4>
+position=< 3,   0> velocity=< 0,  0>`),
+			want: 10,
+		},
+		{
+			// Between -5 and 5 the middle point stays inside the other
+			// two, which keep the same distance apart, so the earliest of
+			// those times wins
+			name: "plateau",
+			points: parsePoints(t, `
+position=< 0, 0> velocity=< 1, 0>
+position=<10, 0> velocity=< 1, 0>
+position=< 5, 0> velocity=< 0, 0>`),
+			want: -5,
+		},
+		{
+			name: "plateau in the far past",
+			points: shiftTime(parsePoints(t, `
+position=< 0, 0> velocity=< 1, 0>
+position=<10, 0> velocity=< 1, 0>
+position=< 5, 0> velocity=< 0, 0>`), 0, -1000000000000),
+			want: -1000000000005,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := findConvergence(test.points)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: converged at %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFindConvergenceParallel(t *testing.T) {
+	points := parsePoints(t, `
+position=< 0, 0> velocity=< 2, 1>
+position=< 5, 7> velocity=< 2, 1>`)
+	if got, err := findConvergence(points); err != errNoConvergence {
+		t.Errorf("converged at %d with error %v, want %v", got, err, errNoConvergence)
+	}
+}
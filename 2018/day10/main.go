@@ -2,11 +2,14 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type point struct {
@@ -55,22 +58,14 @@ func readInput(filename string) ([]Point, error) {
 	return points, nil
 }
 
-func findMessageArrangement(points []Point) (arrangement map[point]bool, messageT int64) {
-	var minArea int64 = math.MaxInt64
-
-	for t := int64(0); ; t++ {
-		pointsAtT := plot(points, t)
-		area := calculateArea(pointsAtT)
-		if area < minArea {
-			minArea = area
-			arrangement = pointsAtT
-			messageT = t
-		} else {
-			break
-		}
+// findMessageArrangement plots the points at the moment they're closest
+// together, which is when they spell out the message.
+func findMessageArrangement(points []Point) (arrangement map[point]bool, messageT int64, err error) {
+	messageT, err = findConvergence(points)
+	if err != nil {
+		return nil, 0, err
 	}
-
-	return
+	return plot(points, messageT), messageT, nil
 }
 
 func plot(points []Point, t int64) map[point]bool {
@@ -87,11 +82,6 @@ func plot(points []Point, t int64) map[point]bool {
 	return result
 }
 
-func calculateArea(points map[point]bool) int64 {
-	min, max := findBounds(points)
-	return (max.X - min.X) * (max.Y - min.Y)
-}
-
 func findBounds(points map[point]bool) (min, max point) {
 	min.X, min.Y = math.MaxInt64, math.MaxInt64
 	max.X, max.Y = math.MinInt64, math.MinInt64
@@ -114,30 +104,78 @@ func findBounds(points map[point]bool) (min, max point) {
 	return
 }
 
+// draw prints the points with a one-point border around them.
 func draw(points map[point]bool) {
 	min, max := findBounds(points)
-	for y := min.Y - 1; y <= max.Y+1; y++ {
-		for x := min.X - 1; x <= max.X+1; x++ {
+	drawWindow(os.Stdout, points, point{X: min.X - 1, Y: min.Y - 1}, point{X: max.X + 1, Y: max.Y + 1})
+	fmt.Println()
+}
+
+// drawWindow prints the part of the sky between min and max, inclusive.
+func drawWindow(w io.Writer, points map[point]bool, min, max point) {
+	out := bufio.NewWriter(w)
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
 			if points[point{X: x, Y: y}] {
-				fmt.Printf("#")
+				out.WriteByte('#')
 			} else {
-				fmt.Printf(".")
+				out.WriteByte('.')
 			}
 		}
-		fmt.Println()
+		out.WriteByte('\n')
 	}
-	fmt.Println()
+	out.Flush()
 }
 
 func main() {
-	filename := "input.txt"
-
-	points, err := readInput(filename)
+	filename := flag.String("input", "input.txt", "star input file")
+	gifFile := flag.String("gif", "", "save an animated GIF of the points around the message to this file")
+	framesDir := flag.String("frames", "", "save PNG frames of the points around the message to this directory")
+	replay := flag.Bool("replay", false, "replay the points around the message in the terminal")
+	window := flag.Int64("window", 10, "seconds either side of the message to animate")
+	scale := flag.Int("scale", 0, "pixels per point in images (0 picks one)")
+	delay := flag.Duration("delay", 100*time.Millisecond, "time between animation frames")
+	flag.Parse()
+
+	points, err := readInput(*filename)
 	if err != nil {
-		log.Fatalf("Error reading input from %s: %s\n", filename, err)
+		log.Fatalf("Error reading input from %s: %s\n", *filename, err)
 	}
 
-	arrangement, t := findMessageArrangement(points)
+	arrangement, t, err := findMessageArrangement(points)
+	if err != nil {
+		log.Fatalf("Error finding message: %s\n", err)
+	}
 	fmt.Printf("After %d seconds:\n", t)
 	draw(arrangement)
+
+	if *gifFile != "" || *framesDir != "" || *replay {
+		animation, err := newAnimation(points, t, *window, *scale, *delay)
+		if err != nil {
+			log.Fatalf("Error setting up animation: %s\n", err)
+		}
+		if *gifFile != "" {
+			if err := animation.writeGIF(*gifFile); err != nil {
+				log.Fatalf("Error writing GIF: %s\n", err)
+			}
+		}
+		if *framesDir != "" {
+			if err := animation.writePNGs(*framesDir); err != nil {
+				log.Fatalf("Error writing frames: %s\n", err)
+			}
+		}
+		if *replay {
+			if err := animation.replay(os.Stdout); err != nil {
+				log.Fatalf("Error replaying animation: %s\n", err)
+			}
+		}
+	}
+
+	// The drawing above is the answer, so a message the fonts can't read
+	// is only worth a warning
+	if message, err := readMessage(arrangement); err != nil {
+		log.Printf("Warning: couldn't read message: %s\n", err)
+	} else {
+		fmt.Printf("Message: %s\n", message)
+	}
 }
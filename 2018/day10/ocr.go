@@ -0,0 +1,434 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Font is a fixed-width bitmap font the messages are written in.
+type Font struct {
+	Name          string
+	Width, Height int
+	// Spacing is the number of empty columns between glyphs
+	Spacing int
+	// glyphs maps each glyph's bitmap, as rows of '#' and '.' joined by
+	// newlines, to the letter it shows
+	glyphs map[string]rune
+}
+
+// newFont builds a font from letters drawn as '#' and '.' rows. Blank lines
+// around each drawing are ignored.
+func newFont(name string, width, height, spacing int, letters map[rune]string) Font {
+	font := Font{Name: name, Width: width, Height: height, Spacing: spacing, glyphs: make(map[string]rune, len(letters))}
+	for letter, drawing := range letters {
+		rows := strings.Split(strings.TrimSpace(drawing), "\n")
+		if len(rows) != height {
+			panic(fmt.Sprintf("%s glyph %c is %d rows tall, not %d", name, letter, len(rows), height))
+		}
+		for _, row := range rows {
+			if len(row) != width {
+				panic(fmt.Sprintf("%s glyph %c has a row %d wide, not %d", name, letter, len(row), width))
+			}
+		}
+		font.glyphs[strings.Join(rows, "\n")] = letter
+	}
+	return font
+}
+
+// largeFont is the one the 2018 messages use.
+var largeFont = newFont("6x10", 6, 10, 2, map[rune]string{
+	'A': `
+..##..
+.#..#.
+#....#
+#....#
+#....#
+######
+#....#
+#....#
+#....#
+#....#`,
+	'B': `
+#####.
+#....#
+#....#
+#....#
+#####.
+#....#
+#....#
+#....#
+#....#
+#####.`,
+	'C': `
+.####.
+#....#
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+#....#
+.####.`,
+	'E': `
+######
+#.....
+#.....
+#.....
+#####.
+#.....
+#.....
+#.....
+#.....
+######`,
+	'F': `
+######
+#.....
+#.....
+#.....
+#####.
+#.....
+#.....
+#.....
+#.....
+#.....`,
+	'G': `
+.####.
+#....#
+#.....
+#.....
+#.....
+#..###
+#....#
+#....#
+#...##
+.###.#`,
+	'H': `
+#....#
+#....#
+#....#
+#....#
+######
+#....#
+#....#
+#....#
+#....#
+#....#`,
+	'J': `
+...###
+....#.
+....#.
+....#.
+....#.
+....#.
+....#.
+#...#.
+#...#.
+.###..`,
+	'K': `
+#....#
+#...#.
+#..#..
+#.#...
+##....
+##....
+#.#...
+#..#..
+#...#.
+#....#`,
+	'L': `
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+######`,
+	'N': `
+#....#
+##...#
+##...#
+#.#..#
+#.#..#
+#..#.#
+#..#.#
+#...##
+#...##
+#....#`,
+	'P': `
+#####.
+#....#
+#....#
+#....#
+#####.
+#.....
+#.....
+#.....
+#.....
+#.....`,
+	'R': `
+#####.
+#....#
+#....#
+#....#
+#####.
+#..#..
+#...#.
+#...#.
+#....#
+#....#`,
+	'X': `
+#....#
+#....#
+.#..#.
+.#..#.
+..##..
+..##..
+.#..#.
+.#..#.
+#....#
+#....#`,
+	'Z': `
+######
+.....#
+.....#
+....#.
+...#..
+..#...
+.#....
+#.....
+#.....
+######`,
+})
+
+// smallFont is the one other years' screen-drawing puzzles use.
+var smallFont = newFont("4x6", 4, 6, 1, map[rune]string{
+	'A': `
+.##.
+#..#
+#..#
+####
+#..#
+#..#`,
+	'B': `
+###.
+#..#
+###.
+#..#
+#..#
+###.`,
+	'C': `
+.##.
+#..#
+#...
+#...
+#..#
+.##.`,
+	'E': `
+####
+#...
+###.
+#...
+#...
+####`,
+	'F': `
+####
+#...
+###.
+#...
+#...
+#...`,
+	'G': `
+.##.
+#..#
+#...
+#.##
+#..#
+.###`,
+	'H': `
+#..#
+#..#
+####
+#..#
+#..#
+#..#`,
+	'J': `
+..##
+...#
+...#
+...#
+#..#
+.##.`,
+	'K': `
+#..#
+#.#.
+##..
+#.#.
+#.#.
+#..#`,
+	'L': `
+#...
+#...
+#...
+#...
+#...
+####`,
+	'O': `
+.##.
+#..#
+#..#
+#..#
+#..#
+.##.`,
+	'P': `
+###.
+#..#
+#..#
+###.
+#...
+#...`,
+	'R': `
+###.
+#..#
+#..#
+###.
+#.#.
+#..#`,
+	'S': `
+.###
+#...
+#...
+.##.
+...#
+###.`,
+	'U': `
+#..#
+#..#
+#..#
+#..#
+#..#
+.##.`,
+	'Z': `
+####
+...#
+..#.
+.#..
+#...
+####`,
+})
+
+var fonts = []Font{largeFont, smallFont}
+
+// Glyph is one character cell cut out of a message.
+type Glyph struct {
+	// Index is the glyph's position in the message, from 0
+	Index  int
+	Bitmap string
+}
+
+// UnrecognizedGlyphsError reports the glyphs a message couldn't be read
+// without. Text is the rest of the message, with '?' for each of them.
+type UnrecognizedGlyphsError struct {
+	Font Font
+	Text string
+	// Glyphs are the unrecognized ones, in message order
+	Glyphs []Glyph
+}
+
+func (e *UnrecognizedGlyphsError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "read %q but couldn't recognize %d glyph(s) (the %s font knows %s):",
+		e.Text, len(e.Glyphs), e.Font.Name, fontLetters(e.Font))
+	for _, g := range e.Glyphs {
+		fmt.Fprintf(&b, "\nglyph %d:\n%s", g.Index, g.Bitmap)
+	}
+	return b.String()
+}
+
+// readMessage recognizes the letters the points spell out. The font is
+// chosen by the height of the message, and the glyphs are cut out at the
+// font's fixed pitch. Since a message can start with a letter that doesn't
+// reach its cell's left edge, every alignment of the cells is tried and the
+// one recognizing the most glyphs wins. If any glyphs are left over, the
+// error is an *UnrecognizedGlyphsError.
+func readMessage(points map[point]bool) (string, error) {
+	if len(points) == 0 {
+		return "", fmt.Errorf("no points to read")
+	}
+
+	min, max := findBounds(points)
+	height := int(max.Y - min.Y + 1)
+
+	var font Font
+	found := false
+	for _, f := range fonts {
+		if f.Height == height {
+			font, found = f, true
+			break
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("message is %d points tall, which matches no font", height)
+	}
+
+	var best *UnrecognizedGlyphsError
+	for shift := 0; shift < font.Width; shift++ {
+		attempt := readGlyphs(points, font, min.X-int64(shift), max.X, min.Y)
+		if best == nil || len(attempt.Glyphs) < len(best.Glyphs) {
+			best = attempt
+		}
+		if len(best.Glyphs) == 0 {
+			return best.Text, nil
+		}
+	}
+
+	return best.Text, best
+}
+
+// readGlyphs cuts the message into cells starting at left and recognizes
+// each one, returning everything it read along with the glyphs it couldn't.
+func readGlyphs(points map[point]bool, font Font, left, right, top int64) *UnrecognizedGlyphsError {
+	result := &UnrecognizedGlyphsError{Font: font}
+	text := make([]rune, 0)
+
+	pitch := int64(font.Width + font.Spacing)
+	for i, x0 := 0, left; x0 <= right; i, x0 = i+1, x0+pitch {
+		rows := make([]string, font.Height)
+		for y := 0; y < font.Height; y++ {
+			row := make([]byte, font.Width)
+			for x := range row {
+				if points[point{X: x0 + int64(x), Y: top + int64(y)}] {
+					row[x] = '#'
+				} else {
+					row[x] = '.'
+				}
+			}
+			rows[y] = string(row)
+		}
+		bitmap := strings.Join(rows, "\n")
+
+		if letter, ok := font.glyphs[bitmap]; ok {
+			text = append(text, letter)
+		} else {
+			text = append(text, '?')
+			result.Glyphs = append(result.Glyphs, Glyph{Index: i, Bitmap: bitmap})
+		}
+	}
+
+	result.Text = string(text)
+	return result
+}
+
+// fontLetters lists the letters a font knows, for error messages.
+func fontLetters(font Font) string {
+	letters := make([]rune, 0, len(font.glyphs))
+	for _, letter := range font.glyphs {
+		letters = append(letters, letter)
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+	return string(letters)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"math"
+	"runtime"
+	"testing"
+)
+
+// mapPowerTable is the summed-area table PowerGrid used to keep in a map,
+// kept to benchmark against.
+type mapPowerTable map[Cell]int64
+
+func newMapPowerTable(grid PowerGrid) mapPowerTable {
+	spt := make(mapPowerTable)
+	for x := int64(1); x <= grid.Width; x++ {
+		for y := int64(1); y <= grid.Height; y++ {
+			power := grid.CalculatePowerAt(x, y)
+			spt[Cell{X: x, Y: y}] = power + spt[Cell{X: x, Y: y - 1}] + spt[Cell{X: x - 1, Y: y}] - spt[Cell{X: x - 1, Y: y - 1}]
+		}
+	}
+	return spt
+}
+
+func (spt mapPowerTable) GetPowerWithin(topLeft, bottomRight Cell) int64 {
+	a := Cell{X: topLeft.X - 1, Y: topLeft.Y - 1}
+	b := Cell{X: bottomRight.X, Y: a.Y}
+	c := Cell{X: a.X, Y: bottomRight.Y}
+	d := Cell{X: bottomRight.X, Y: bottomRight.Y}
+	return spt[d] + spt[a] - spt[b] - spt[c]
+}
+
+// findOverallLargestTotalPowerMap is a single-threaded
+// findOverallLargestTotalPower on a mapPowerTable.
+func findOverallLargestTotalPowerMap(grid PowerGrid, spt mapPowerTable) (topLeft Cell, bestSize, totalPower int64) {
+	totalPower = math.MinInt64
+
+	for size := int64(1); size <= grid.Width && size <= grid.Height; size++ {
+		for y := int64(1); y+size-1 <= grid.Height; y++ {
+			for x := int64(1); x+size-1 <= grid.Width; x++ {
+				tL := Cell{X: x, Y: y}
+				bR := Cell{X: x + size - 1, Y: y + size - 1}
+				if power := spt.GetPowerWithin(tL, bR); power > totalPower {
+					topLeft = tL
+					bestSize = size
+					totalPower = power
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// benchmarkGrid is the puzzle's grid for the default serial number.
+var benchmarkGrid = NewPowerGrid(300, 300, 4842)
+
+func TestMapPowerTable(t *testing.T) {
+	grid := benchmarkGrid
+	topLeft, size, power := findOverallLargestTotalPower(grid, SearchOptions{Workers: 1})
+	mapTopLeft, mapSize, mapPower := findOverallLargestTotalPowerMap(grid, newMapPowerTable(grid))
+	if topLeft != mapTopLeft || size != mapSize || power != mapPower {
+		t.Fatalf(
+			"slice table found %+v size %d (power %d) but map table found %+v size %d (power %d)",
+			topLeft, size, power, mapTopLeft, mapSize, mapPower,
+		)
+	}
+}
+
+func BenchmarkBuildMap(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		newMapPowerTable(benchmarkGrid)
+	}
+}
+
+func BenchmarkBuildSAT(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewPowerGrid(benchmarkGrid.Width, benchmarkGrid.Height, benchmarkGrid.Serial)
+	}
+}
+
+func BenchmarkSearchMap(b *testing.B) {
+	spt := newMapPowerTable(benchmarkGrid)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		findOverallLargestTotalPowerMap(benchmarkGrid, spt)
+	}
+}
+
+// BenchmarkSearchSAT searches with one worker, to compare like with like
+// with BenchmarkSearchMap.
+func BenchmarkSearchSAT(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		findOverallLargestTotalPower(benchmarkGrid, SearchOptions{Workers: 1})
+	}
+}
+
+func BenchmarkSearchSATParallel(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		findOverallLargestTotalPower(benchmarkGrid, SearchOptions{Workers: runtime.NumCPU()})
+	}
+}
+
+func BenchmarkSearchSATEarlyStop(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		findOverallLargestTotalPower(benchmarkGrid, SearchOptions{Workers: 1, EarlyStop: true})
+	}
+}
+
+func BenchmarkSearchSATParallelEarlyStop(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		findOverallLargestTotalPower(benchmarkGrid, SearchOptions{Workers: runtime.NumCPU(), EarlyStop: true})
+	}
+}
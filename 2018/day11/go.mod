@@ -1 +1,3 @@
 module github.com/adamrothman/adventofcode/2018/day11
+
+go 1.18
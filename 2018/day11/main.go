@@ -1,8 +1,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log"
 	"math"
+	"os"
+	"runtime"
+
+	"github.com/adamrothman/adventofcode/2018/day11/sat"
 )
 
 type Cell struct {
@@ -13,7 +19,7 @@ type PowerGrid struct {
 	Width, Height int64
 	Serial        int64
 
-	summedPowerTable map[Cell]int64
+	summedPowerTable *sat.Table[int64]
 }
 
 func NewPowerGrid(width, height, serial int64) PowerGrid {
@@ -22,18 +28,20 @@ func NewPowerGrid(width, height, serial int64) PowerGrid {
 		Height: height,
 		Serial: serial,
 	}
+	return NewPowerGridFunc(width, height, serial, grid.CalculatePowerAt)
+}
 
-	// https://en.wikipedia.org/wiki/Summed-area_table
-	spt := make(map[Cell]int64)
-	for x := int64(1); x <= width; x++ {
-		for y := int64(1); y <= height; y++ {
-			power := grid.CalculatePowerAt(x, y)
-			spt[Cell{X: x, Y: y}] = power + spt[Cell{X: x, Y: y - 1}] + spt[Cell{X: x - 1, Y: y}] - spt[Cell{X: x - 1, Y: y - 1}]
-		}
+// NewPowerGridFunc builds a grid whose cells' power levels come from power
+// rather than CalculatePowerAt. serial is only recorded.
+func NewPowerGridFunc(width, height, serial int64, power func(x, y int64) int64) PowerGrid {
+	return PowerGrid{
+		Width:  width,
+		Height: height,
+		Serial: serial,
+		summedPowerTable: sat.New(int(width), int(height), func(x, y int) int64 {
+			return power(int64(x), int64(y))
+		}),
 	}
-	grid.summedPowerTable = spt
-
-	return grid
 }
 
 func (g PowerGrid) CalculatePowerAt(x, y int64) (power int64) {
@@ -46,23 +54,20 @@ func (g PowerGrid) CalculatePowerAt(x, y int64) (power int64) {
 	return
 }
 
-// https://en.wikipedia.org/wiki/Summed-area_table#The_algorithm
-// Note that our A here is actually 1 unit to the left and above the given
-// topLeft; this is because the canonical formula excludes the top and left
-// sides (which we want to include).
+// GetPowerWithin returns the total power of the cells from topLeft to
+// bottomRight, inclusive.
 func (g PowerGrid) GetPowerWithin(topLeft, bottomRight Cell) int64 {
-	a := Cell{X: topLeft.X - 1, Y: topLeft.Y - 1}
-	b := Cell{X: bottomRight.X, Y: a.Y}
-	c := Cell{X: a.X, Y: bottomRight.Y}
-	d := Cell{X: bottomRight.X, Y: bottomRight.Y}
-	return g.summedPowerTable[d] + g.summedPowerTable[a] - g.summedPowerTable[b] - g.summedPowerTable[c]
+	return g.summedPowerTable.Sum(int(topLeft.X), int(topLeft.Y), int(bottomRight.X), int(bottomRight.Y))
 }
 
+// findLargestTotalPower finds the width x height rectangle with the most
+// power, including those touching the grid's last row and column. Ties go to
+// the first in reading order: topmost, then leftmost.
 func findLargestTotalPower(grid PowerGrid, width, height int64) (topLeft Cell, totalPower int64) {
 	totalPower = math.MinInt64
 
-	for x := int64(1); x+width <= grid.Width; x++ {
-		for y := int64(1); y+height <= grid.Height; y++ {
+	for y := int64(1); y+height-1 <= grid.Height; y++ {
+		for x := int64(1); x+width-1 <= grid.Width; x++ {
 			tL := Cell{X: x, Y: y}
 			bR := Cell{X: x + width - 1, Y: y + height - 1}
 			if power := grid.GetPowerWithin(tL, bR); power > totalPower {
@@ -75,34 +80,70 @@ func findLargestTotalPower(grid PowerGrid, width, height int64) (topLeft Cell, t
 	return
 }
 
-func findOverallLargestTotalPower(grid PowerGrid) (topLeft Cell, bestSize, totalPower int64) {
-	totalPower = math.MinInt64
-
-	for size := int64(1); size < grid.Width; size++ {
-		for x := int64(1); x+size <= grid.Width; x++ {
-			for y := int64(1); y+size <= grid.Height; y++ {
-				tL := Cell{X: x, Y: y}
-				bR := Cell{X: x + size - 1, Y: y + size - 1}
-				if power := grid.GetPowerWithin(tL, bR); power > totalPower {
-					topLeft = tL
-					bestSize = size
-					totalPower = power
-				}
-			}
-		}
-	}
-
-	return
-}
-
 func main() {
-	var serial int64 = 4842
-
-	grid := NewPowerGrid(300, 300, serial)
+	serial := flag.Int64("serial", 4842, "grid serial number")
+	workers := flag.Int("workers", runtime.NumCPU(), "goroutines to search square sizes with")
+	early := flag.Bool("early", false, "stop searching once larger squares provably can't win")
+	rect := flag.Bool("rect", false, "also find the rectangle of any shape with the most power")
+	maxWidth := flag.Int64("maxwidth", 0, "limit -rect to rectangles at most this wide (0 for no limit)")
+	maxHeight := flag.Int64("maxheight", 0, "limit -rect to rectangles at most this tall (0 for no limit)")
+	heatmap := flag.String("heatmap", "", "save a heatmap of the power levels to this PNG file")
+	serials := flag.String("serials", "", "comma-separated serial numbers to draw side by side in -heatmap (default -serial)")
+	scale := flag.Int("scale", 3, "pixels per cell in -heatmap")
+	window := flag.String("window", "", "print the power levels in a window: x,y,width,height or best")
+	flag.Parse()
+
+	grid := NewPowerGrid(300, 300, *serial)
 
 	topLeft, totalPower := findLargestTotalPower(grid, 3, 3)
 	fmt.Printf("Top left cell of 3x3 square with largest total power: %+v (power %d)\n", topLeft, totalPower)
 
-	topLeft, bestSize, totalPower := findOverallLargestTotalPower(grid)
+	topLeft, bestSize, totalPower := findOverallLargestTotalPower(grid, SearchOptions{Workers: *workers, EarlyStop: *early})
 	fmt.Printf("Square with largest total power has top left %+v and size %d (total power %d)\n", topLeft, bestSize, totalPower)
+
+	if *rect {
+		if *maxWidth < 0 || *maxHeight < 0 {
+			log.Fatalf("Error: rectangle limits must not be negative, got %dx%d\n", *maxWidth, *maxHeight)
+		}
+		width, height := *maxWidth, *maxHeight
+		if width == 0 {
+			width = grid.Width
+		}
+		if height == 0 {
+			height = grid.Height
+		}
+		best, totalPower := findLargestRectangleWithin(grid, width, height)
+		fmt.Printf("Rectangle at most %dx%d with largest total power: %s (total power %d)\n", width, height, best, totalPower)
+	}
+
+	if *window != "" {
+		r, err := parseWindow(*window, grid)
+		if err != nil {
+			log.Fatalf("Error parsing -window: %s\n", err)
+		}
+		if err := dumpWindow(os.Stdout, grid, r); err != nil {
+			log.Fatalf("Error printing window: %s\n", err)
+		}
+	}
+
+	if *heatmap != "" {
+		if *scale < 1 {
+			log.Fatalf("Error: scale must be at least 1, got %d\n", *scale)
+		}
+		grids := []PowerGrid{grid}
+		if *serials != "" {
+			list, err := parseSerials(*serials)
+			if err != nil {
+				log.Fatalf("Error parsing -serials: %s\n", err)
+			}
+			grids = make([]PowerGrid, len(list))
+			for i, serial := range list {
+				grids[i] = NewPowerGrid(grid.Width, grid.Height, serial)
+			}
+		}
+		img := renderHeatmaps(grids, *scale, SearchOptions{Workers: *workers, EarlyStop: *early})
+		if err := writePNG(*heatmap, img); err != nil {
+			log.Fatalf("Error writing heatmap: %s\n", err)
+		}
+	}
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rectangle is the block of cells from TopLeft to BottomRight, inclusive.
+type Rectangle struct {
+	TopLeft, BottomRight Cell
+}
+
+func (r Rectangle) Width() int64 {
+	return r.BottomRight.X - r.TopLeft.X + 1
+}
+
+func (r Rectangle) Height() int64 {
+	return r.BottomRight.Y - r.TopLeft.Y + 1
+}
+
+func (r Rectangle) String() string {
+	return fmt.Sprintf("%d,%d %dx%d", r.TopLeft.X, r.TopLeft.Y, r.Width(), r.Height())
+}
+
+// findLargestRectangle finds the rectangle of any shape with the most power.
+func findLargestRectangle(grid PowerGrid) (Rectangle, int64) {
+	return findLargestRectangleWithin(grid, grid.Width, grid.Height)
+}
+
+// findLargestRectangleWithin finds the rectangle with the most power that's
+// at most maxWidth wide and maxHeight tall. If no rectangle fits, it returns
+// a power of math.MinInt64, like findLargestTotalPower.
+//
+// This is 2D Kadane: for every pair of top and bottom rows, find the best run
+// of columns between them. The summed-area table gives the power of columns 1
+// to x between the rows directly, so the best run ending at x is that less
+// the smallest such prefix within maxWidth columns before it, which a
+// monotonic queue tracks as x advances. That's O(width) per pair of rows, and
+// O(width * height * maxHeight) overall.
+//
+// Ties go to the topmost rectangle, then the shortest, then the one whose
+// right edge is furthest left, then the narrowest.
+func findLargestRectangleWithin(grid PowerGrid, maxWidth, maxHeight int64) (best Rectangle, totalPower int64) {
+	if maxWidth > grid.Width {
+		maxWidth = grid.Width
+	}
+	if maxHeight > grid.Height {
+		maxHeight = grid.Height
+	}
+	totalPower = math.MinInt64
+	if maxWidth < 1 || maxHeight < 1 {
+		return
+	}
+
+	// prefix[x] is the power of columns 1 to x between the current rows
+	prefix := make([]int64, grid.Width+1)
+	// queue holds candidate left edges (less one), with increasing prefixes
+	queue := make([]int64, 0, grid.Width)
+
+	for top := int64(1); top <= grid.Height; top++ {
+		for bottom := top; bottom <= grid.Height && bottom-top < maxHeight; bottom++ {
+			for x := int64(1); x <= grid.Width; x++ {
+				prefix[x] = grid.GetPowerWithin(Cell{X: 1, Y: top}, Cell{X: x, Y: bottom})
+			}
+
+			queue = queue[:0]
+			for right := int64(1); right <= grid.Width; right++ {
+				// Prefer the later of two equal prefixes, for a narrower
+				// rectangle
+				for len(queue) > 0 && prefix[queue[len(queue)-1]] >= prefix[right-1] {
+					queue = queue[:len(queue)-1]
+				}
+				queue = append(queue, right-1)
+				for queue[0] < right-maxWidth {
+					queue = queue[1:]
+				}
+
+				left := queue[0]
+				if power := prefix[right] - prefix[left]; power > totalPower {
+					best = Rectangle{TopLeft: Cell{X: left + 1, Y: top}, BottomRight: Cell{X: right, Y: bottom}}
+					totalPower = power
+				}
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// bruteForceRectangle finds the best rectangle within the limits by adding
+// up each one's cells directly, breaking ties the same way as
+// findLargestRectangleWithin.
+func bruteForceRectangle(width, height int64, power func(x, y int64) int64, maxWidth, maxHeight int64) (best Rectangle, totalPower int64) {
+	totalPower = math.MinInt64
+	for top := int64(1); top <= height; top++ {
+		for bottom := top; bottom <= height && bottom-top < maxHeight; bottom++ {
+			for right := int64(1); right <= width; right++ {
+				for left := right; left >= 1 && right-left < maxWidth; left-- {
+					var sum int64
+					for y := top; y <= bottom; y++ {
+						for x := left; x <= right; x++ {
+							sum += power(x, y)
+						}
+					}
+					if sum > totalPower {
+						best = Rectangle{TopLeft: Cell{X: left, Y: top}, BottomRight: Cell{X: right, Y: bottom}}
+						totalPower = sum
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+func TestFindLargestRectangleWithin(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		grid, power := randomGrid(r, i)
+		width, height := grid.Width, grid.Height
+		for _, limits := range [][2]int64{{width, height}, {1, height}, {width, 1}, {3, 2}} {
+			rect, totalPower := findLargestRectangleWithin(grid, limits[0], limits[1])
+			wantRect, wantPower := bruteForceRectangle(width, height, power, limits[0], limits[1])
+			if rect != wantRect || totalPower != wantPower {
+				t.Fatalf(
+					"%dx%d grid %d within %dx%d: found %s (power %d), want %s (power %d)",
+					width, height, i, limits[0], limits[1], rect, totalPower, wantRect, wantPower,
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	minPower = -5
+	maxPower = 4
+	// panelGap is the space between grids rendered side by side, in pixels
+	panelGap = 16
+)
+
+var (
+	backgroundColor = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	best3x3Color    = color.RGBA{R: 0x00, G: 0xc0, B: 0x00, A: 0xff}
+	bestSquareColor = color.RGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff}
+)
+
+// heatColor shades power levels from blue at -5 through white to red at 4.
+func heatColor(power int64) color.RGBA {
+	if power < minPower {
+		power = minPower
+	}
+	if power > maxPower {
+		power = maxPower
+	}
+	if power < 0 {
+		f := float64(power) / minPower
+		fade := uint8(255 * (1 - f))
+		return color.RGBA{R: fade, G: fade, B: 0xff, A: 0xff}
+	}
+	f := float64(power) / maxPower
+	fade := uint8(255 * (1 - f))
+	return color.RGBA{R: 0xff, G: fade, B: fade, A: 0xff}
+}
+
+// renderHeatmaps draws each grid's power levels, left to right, with its
+// best 3x3 square and best square of any size outlined.
+func renderHeatmaps(grids []PowerGrid, scale int, options SearchOptions) *image.RGBA {
+	width, height := 0, 0
+	for i, grid := range grids {
+		if i > 0 {
+			width += panelGap
+		}
+		width += int(grid.Width) * scale
+		if h := int(grid.Height) * scale; h > height {
+			height = h
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, backgroundColor)
+		}
+	}
+
+	left := 0
+	for _, grid := range grids {
+		for y := int64(1); y <= grid.Height; y++ {
+			for x := int64(1); x <= grid.Width; x++ {
+				c := heatColor(grid.GetPowerWithin(Cell{X: x, Y: y}, Cell{X: x, Y: y}))
+				px := left + int(x-1)*scale
+				py := int(y-1) * scale
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						img.SetRGBA(px+dx, py+dy, c)
+					}
+				}
+			}
+		}
+
+		if topLeft, size, power := findOverallLargestTotalPower(grid, options); power != math.MinInt64 {
+			outlineRectangle(img, left, scale, squareAt(topLeft, size), bestSquareColor)
+		}
+		if topLeft, power := findLargestTotalPower(grid, 3, 3); power != math.MinInt64 {
+			outlineRectangle(img, left, scale, squareAt(topLeft, 3), best3x3Color)
+		}
+
+		left += int(grid.Width)*scale + panelGap
+	}
+
+	return img
+}
+
+func squareAt(topLeft Cell, size int64) Rectangle {
+	return Rectangle{TopLeft: topLeft, BottomRight: Cell{X: topLeft.X + size - 1, Y: topLeft.Y + size - 1}}
+}
+
+// outlineRectangle draws a two pixel border just outside r, in a panel whose
+// left edge is at left, so the cells inside stay visible.
+func outlineRectangle(img *image.RGBA, left, scale int, r Rectangle, c color.RGBA) {
+	x0 := left + int(r.TopLeft.X-1)*scale - 2
+	y0 := int(r.TopLeft.Y-1)*scale - 2
+	x1 := left + int(r.BottomRight.X)*scale + 1
+	y1 := int(r.BottomRight.Y)*scale + 1
+
+	set := func(x, y int) {
+		if image.Pt(x, y).In(img.Rect) {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	for t := 0; t < 2; t++ {
+		for x := x0; x <= x1; x++ {
+			set(x, y0+t)
+			set(x, y1-t)
+		}
+		for y := y0; y <= y1; y++ {
+			set(x0+t, y)
+			set(x1-t, y)
+		}
+	}
+}
+
+func writePNG(filename string, img image.Image) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("creating image file %s: %s", filename, err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("encoding PNG: %s", err)
+	}
+
+	return f.Close()
+}
+
+// dumpWindow prints the power levels in r, like the puzzle's excerpts.
+func dumpWindow(w io.Writer, grid PowerGrid, r Rectangle) error {
+	if r.TopLeft.X < 1 || r.TopLeft.Y < 1 || r.BottomRight.X > grid.Width || r.BottomRight.Y > grid.Height || r.Width() < 1 || r.Height() < 1 {
+		return fmt.Errorf("window %s isn't within the %dx%d grid", r, grid.Width, grid.Height)
+	}
+
+	out := bufio.NewWriter(w)
+	for y := r.TopLeft.Y; y <= r.BottomRight.Y; y++ {
+		values := make([]string, 0, r.Width())
+		for x := r.TopLeft.X; x <= r.BottomRight.X; x++ {
+			values = append(values, fmt.Sprintf("%2d", grid.GetPowerWithin(Cell{X: x, Y: y}, Cell{X: x, Y: y})))
+		}
+		fmt.Fprintln(out, strings.Join(values, "  "))
+	}
+	return out.Flush()
+}
+
+// parseWindow parses a window given as "x,y,width,height", or "best" for the
+// best 3x3 square with a one cell border, like the puzzle's excerpts.
+func parseWindow(s string, grid PowerGrid) (Rectangle, error) {
+	if s == "best" {
+		topLeft, power := findLargestTotalPower(grid, 3, 3)
+		if power == math.MinInt64 {
+			return Rectangle{}, fmt.Errorf("no 3x3 square fits in the %dx%d grid", grid.Width, grid.Height)
+		}
+		r := squareAt(topLeft, 3)
+		if r.TopLeft.X > 1 {
+			r.TopLeft.X--
+		}
+		if r.TopLeft.Y > 1 {
+			r.TopLeft.Y--
+		}
+		if r.BottomRight.X < grid.Width {
+			r.BottomRight.X++
+		}
+		if r.BottomRight.Y < grid.Height {
+			r.BottomRight.Y++
+		}
+		return r, nil
+	}
+
+	fields := strings.Split(s, ",")
+	if len(fields) != 4 {
+		return Rectangle{}, fmt.Errorf("window %q should be x,y,width,height or best", s)
+	}
+	n := make([]int64, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return Rectangle{}, fmt.Errorf("parsing window %q: %s", s, err)
+		}
+		n[i] = value
+	}
+	return Rectangle{TopLeft: Cell{X: n[0], Y: n[1]}, BottomRight: Cell{X: n[0] + n[2] - 1, Y: n[1] + n[3] - 1}}, nil
+}
+
+// parseSerials parses a comma-separated list of serial numbers.
+func parseSerials(s string) ([]int64, error) {
+	serials := make([]int64, 0)
+	for _, field := range strings.Split(s, ",") {
+		serial, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing serial %q: %s", field, err)
+		}
+		serials = append(serials, serial)
+	}
+	return serials, nil
+}
@@ -0,0 +1,75 @@
+// Package sat implements summed-area tables, which give the sum of any
+// rectangle of a grid in constant time.
+//
+// https://en.wikipedia.org/wiki/Summed-area_table
+package sat
+
+import "fmt"
+
+// Integer is the set of types a Table can sum.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Table holds the sums of a width x height grid whose cells are numbered
+// from 1, like the puzzles number them. It's stored in a flat slice with an
+// extra row and column of zeros at the top and left, so the sums used by Sum
+// never need bounds checks of their own.
+type Table[T Integer] struct {
+	width, height int
+	// sums[y*(width+1)+x] is the sum of every cell from (1, 1) to (x, y)
+	sums []T
+}
+
+// New builds the table for a grid whose cell (x, y) holds value(x, y).
+func New[T Integer](width, height int, value func(x, y int) T) *Table[T] {
+	if width < 0 || height < 0 {
+		panic(fmt.Sprintf("sat: negative size %dx%d", width, height))
+	}
+
+	stride := width + 1
+	t := &Table[T]{
+		width:  width,
+		height: height,
+		sums:   make([]T, stride*(height+1)),
+	}
+	for y := 1; y <= height; y++ {
+		var row T
+		for x := 1; x <= width; x++ {
+			row += value(x, y)
+			t.sums[y*stride+x] = row + t.sums[(y-1)*stride+x]
+		}
+	}
+
+	return t
+}
+
+// Width returns the number of columns in the grid.
+func (t *Table[T]) Width() int {
+	return t.width
+}
+
+// Height returns the number of rows in the grid.
+func (t *Table[T]) Height() int {
+	return t.height
+}
+
+// Sum returns the total of the cells from (x0, y0) to (x1, y1), inclusive.
+// It panics if the rectangle isn't within the grid.
+func (t *Table[T]) Sum(x0, y0, x1, y1 int) T {
+	if x0 < 1 || y0 < 1 || x1 > t.width || y1 > t.height || x0 > x1+1 || y0 > y1+1 {
+		panic(fmt.Sprintf("sat: rectangle (%d, %d)-(%d, %d) out of range for %dx%d grid", x0, y0, x1, y1, t.width, t.height))
+	}
+
+	stride := t.width + 1
+	// The canonical formula excludes the top and left sides, so start one
+	// row and column before them
+	above, below := (y0-1)*stride, y1*stride
+	return t.sums[below+x1] - t.sums[above+x1] - t.sums[below+x0-1] + t.sums[above+x0-1]
+}
+
+// At returns the value of cell (x, y).
+func (t *Table[T]) At(x, y int) T {
+	return t.Sum(x, y, x, y)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"math"
+	"sync"
+)
+
+// SearchOptions control findOverallLargestTotalPower.
+type SearchOptions struct {
+	// Workers is the number of goroutines searching sizes at once
+	Workers int
+	// EarlyStop skips the larger sizes once partitionBound shows none of
+	// them can beat the best square found so far. The result is the same
+	// either way.
+	EarlyStop bool
+}
+
+// sizeResult is the best square of one size.
+type sizeResult struct {
+	searched bool
+	topLeft  Cell
+	power    int64
+}
+
+// findOverallLargestTotalPower finds the square of any size with the most
+// power. Each size is searched independently, shared out between the
+// workers, and ties go to the smallest size, then to the first square in
+// reading order.
+func findOverallLargestTotalPower(grid PowerGrid, options SearchOptions) (topLeft Cell, bestSize, totalPower int64) {
+	maxSize := grid.Width
+	if grid.Height < maxSize {
+		maxSize = grid.Height
+	}
+	workers := options.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]sizeResult, maxSize+1)
+
+	var mu sync.Mutex
+	nextSize := int64(1)
+	// Sizes from stopSize on are skipped
+	stopSize := maxSize + 1
+	best := int64(math.MinInt64)
+	// Bounds are only worth computing once squares start losing power, and
+	// each one that fails puts off the next by a quarter of the size
+	nextCheck := int64(1)
+	checking := false
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				size := nextSize
+				if size >= stopSize {
+					mu.Unlock()
+					return
+				}
+				nextSize++
+				mu.Unlock()
+
+				tL, power := findLargestTotalPower(grid, size, size)
+				// Each size has its own slot, so storing it needs no lock
+				results[size] = sizeResult{searched: true, topLeft: tL, power: power}
+				if !options.EarlyStop {
+					continue
+				}
+
+				mu.Lock()
+				if power > best {
+					best = power
+				}
+				check := power < 0 && size >= nextCheck && !checking
+				if check {
+					checking = true
+					nextCheck = size + size/4 + 1
+				}
+				mu.Unlock()
+				if !check {
+					continue
+				}
+
+				// Every size up to this one has been handed out, so if no
+				// larger square can match the best one found, they're all
+				// that's left to finish. A larger size already being
+				// searched can't change the answer either.
+				mu.Lock()
+				limit := best
+				mu.Unlock()
+				if limit > 0 {
+					limit = 0
+				}
+				bound := partitionBound(grid, size+1, limit)
+				mu.Lock()
+				if bound < limit && size+1 < stopSize {
+					stopSize = size + 1
+				}
+				checking = false
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	totalPower = math.MinInt64
+	for size := int64(1); size <= maxSize; size++ {
+		if result := results[size]; result.searched && result.power > totalPower {
+			topLeft = result.topLeft
+			bestSize = size
+			totalPower = result.power
+		}
+	}
+
+	return
+}
+
+// partitionBound returns the most power of any rectangle whose sides are
+// both between a and 2a-1 cells long. If that's negative, it's also more
+// than any square of size a or larger can have: splitting each side into
+// pieces between a and 2a-1 long cuts the square into such rectangles, and
+// adding negative powers only lowers the total. If no rectangle fits, it
+// returns math.MinInt64.
+//
+// Once it finds a rectangle with at least limit power it returns that
+// power without looking any further, since the caller can't use a bound
+// that high.
+//
+// It's findLargestRectangleWithin with a minimum width as well as a maximum,
+// so each left edge only joins the queue once it's a columns back.
+func partitionBound(grid PowerGrid, a, limit int64) int64 {
+	bound := int64(math.MinInt64)
+	maxSide := 2*a - 1
+
+	// rows[y][x] is the power of row y from column 1 to x, so adding rows
+	// up extends the strip one row at a time instead of asking the table
+	// for every column again
+	rows := make([][]int64, grid.Height+1)
+	for y := int64(1); y <= grid.Height; y++ {
+		rows[y] = make([]int64, grid.Width+1)
+		for x := int64(1); x <= grid.Width; x++ {
+			rows[y][x] = grid.GetPowerWithin(Cell{X: 1, Y: y}, Cell{X: x, Y: y})
+		}
+	}
+
+	prefix := make([]int64, grid.Width+1)
+	queue := make([]int64, 0, grid.Width)
+
+	for top := int64(1); top+a-1 <= grid.Height; top++ {
+		for x := range prefix {
+			prefix[x] = 0
+		}
+		for y := top; y < top+a-1; y++ {
+			for x, power := range rows[y] {
+				prefix[x] += power
+			}
+		}
+
+		for bottom := top + a - 1; bottom <= grid.Height && bottom-top < maxSide; bottom++ {
+			for x, power := range rows[bottom] {
+				prefix[x] += power
+			}
+
+			queue = queue[:0]
+			for right := a; right <= grid.Width; right++ {
+				left := right - a
+				for len(queue) > 0 && prefix[queue[len(queue)-1]] >= prefix[left] {
+					queue = queue[:len(queue)-1]
+				}
+				queue = append(queue, left)
+				for queue[0] < right-maxSide {
+					queue = queue[1:]
+				}
+
+				if power := prefix[right] - prefix[queue[0]]; power > bound {
+					bound = power
+					if bound >= limit {
+						return bound
+					}
+				}
+			}
+		}
+	}
+
+	return bound
+}
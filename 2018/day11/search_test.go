@@ -0,0 +1,149 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// bruteForceLargest finds the best square by adding up each one's cells
+// directly, for checking the faster searches against.
+func bruteForceLargest(width, height int64, power func(x, y int64) int64, sizes []int64) (topLeft Cell, bestSize, totalPower int64) {
+	totalPower = math.MinInt64
+	for _, size := range sizes {
+		for y := int64(1); y+size-1 <= height; y++ {
+			for x := int64(1); x+size-1 <= width; x++ {
+				var sum int64
+				for dy := int64(0); dy < size; dy++ {
+					for dx := int64(0); dx < size; dx++ {
+						sum += power(x+dx, y+dy)
+					}
+				}
+				if sum > totalPower {
+					topLeft = Cell{X: x, Y: y}
+					bestSize = size
+					totalPower = sum
+				}
+			}
+		}
+	}
+	return
+}
+
+// randomGrid makes the i-th small grid for checking searches against brute
+// force. Every fourth one puts its only positive cells along the last row and
+// column, where squares used to be missed.
+func randomGrid(r *rand.Rand, i int) (grid PowerGrid, power func(x, y int64) int64) {
+	width, height := int64(1+r.Intn(12)), int64(1+r.Intn(12))
+	cells := make([]int64, width*height)
+	for c := range cells {
+		if i%4 == 0 {
+			cells[c] = -1
+		} else {
+			cells[c] = int64(r.Intn(10) - 5)
+		}
+	}
+	if i%4 == 0 {
+		for x := int64(1); x <= width; x++ {
+			cells[(height-1)*width+x-1] = 4
+		}
+		for y := int64(1); y <= height; y++ {
+			cells[(y-1)*width+width-1] = 4
+		}
+	}
+	power = func(x, y int64) int64 { return cells[(y-1)*width+x-1] }
+	return NewPowerGridFunc(width, height, 0, power), power
+}
+
+func TestFindLargestTotalPower(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		grid, power := randomGrid(r, i)
+		for size := int64(1); size <= grid.Width && size <= grid.Height; size++ {
+			topLeft, totalPower := findLargestTotalPower(grid, size, size)
+			wantTopLeft, _, wantPower := bruteForceLargest(grid.Width, grid.Height, power, []int64{size})
+			if topLeft != wantTopLeft || totalPower != wantPower {
+				t.Fatalf(
+					"%dx%d grid %d, size %d: found %+v (power %d), want %+v (power %d)",
+					grid.Width, grid.Height, i, size, topLeft, totalPower, wantTopLeft, wantPower,
+				)
+			}
+		}
+	}
+}
+
+func TestFindOverallLargestTotalPower(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		grid, power := randomGrid(r, i)
+		sizes := make([]int64, 0)
+		for size := int64(1); size <= grid.Width && size <= grid.Height; size++ {
+			sizes = append(sizes, size)
+		}
+		wantTopLeft, wantSize, wantPower := bruteForceLargest(grid.Width, grid.Height, power, sizes)
+
+		for _, options := range []SearchOptions{
+			{Workers: 1},
+			{Workers: 4},
+			{Workers: 1, EarlyStop: true},
+			{Workers: 4, EarlyStop: true},
+		} {
+			topLeft, size, totalPower := findOverallLargestTotalPower(grid, options)
+			if topLeft != wantTopLeft || size != wantSize || totalPower != wantPower {
+				t.Fatalf(
+					"%dx%d grid %d with %+v: found %+v size %d (power %d), want %+v size %d (power %d)",
+					grid.Width, grid.Height, i, options, topLeft, size, totalPower, wantTopLeft, wantSize, wantPower,
+				)
+			}
+		}
+	}
+}
+
+func TestPartitionBound(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		grid, power := randomGrid(r, i)
+		for a := int64(1); a <= grid.Width && a <= grid.Height; a++ {
+			want := int64(math.MinInt64)
+			for h := a; h < 2*a && h <= grid.Height; h++ {
+				for w := a; w < 2*a && w <= grid.Width; w++ {
+					for y := int64(1); y+h-1 <= grid.Height; y++ {
+						for x := int64(1); x+w-1 <= grid.Width; x++ {
+							var sum int64
+							for dy := int64(0); dy < h; dy++ {
+								for dx := int64(0); dx < w; dx++ {
+									sum += power(x+dx, y+dy)
+								}
+							}
+							if sum > want {
+								want = sum
+							}
+						}
+					}
+				}
+			}
+
+			if bound := partitionBound(grid, a, math.MaxInt64); bound != want {
+				t.Fatalf("%dx%d grid %d, a = %d: bound %d, want %d", grid.Width, grid.Height, i, a, bound, want)
+			}
+		}
+	}
+}
+
+func TestEarlyStop(t *testing.T) {
+	grid := NewPowerGrid(300, 300, 4842)
+	topLeft, size, power := findOverallLargestTotalPower(grid, SearchOptions{Workers: 1})
+
+	for _, options := range []SearchOptions{{Workers: 1, EarlyStop: true}, {Workers: 4, EarlyStop: true}} {
+		earlyTopLeft, earlySize, earlyPower := findOverallLargestTotalPower(grid, options)
+		if earlyTopLeft != topLeft || earlySize != size || earlyPower != power {
+			t.Errorf(
+				"with %+v: found %+v size %d (power %d), want %+v size %d (power %d)",
+				options, earlyTopLeft, earlySize, earlyPower, topLeft, size, power,
+			)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+)
+
+// maxCycleSearch is how many generations advance remembers while looking for
+// a repeat, which bounds its memory.
+const maxCycleSearch = 1000000
+
+// maxSearchWords bounds the work advance does looking for a repeat, counted
+// in words stepped. Patterns that keep growing never repeat, and get slower
+// to step as they grow, so they'd hit it long before maxCycleSearch.
+const maxSearchWords = 1 << 24
+
+// Cycle describes generations that repeat: from generation Start on, each
+// generation is the one Period before it, moved Drift pots to the right.
+type Cycle struct {
+	Start, Period, Drift int
+}
+
+// patternHash identifies a generation's arrangement of plants wherever it is
+// along the row. PackedGenerations are trimmed to start at their first
+// plant, so equal patterns have equal words and so equal hashes.
+func (p PackedGeneration) patternHash() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, w := range p.words {
+		binary.LittleEndian.PutUint64(buf[:], w)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// advance computes the given generation. It remembers a hash of every
+// pattern it sees, and at the first that repeats an earlier one it knows the
+// rest of the generations cycle: the rules don't depend on where along the
+// row a pattern is, so whatever followed the earlier one follows this one
+// too, shifted by however far the pattern has drifted. That lets it skip
+// every whole cycle and only simulate what's left. Since hashes can collide,
+// a repeat is only trusted once the earlier generation has been simulated
+// again and compared. The cycle is nil if none was needed.
+func advance(initial Generation, table RuleTable, generations int) (PackedGeneration, *Cycle, error) {
+	type sighting struct {
+		gen, offset, length int
+	}
+	seen := make(map[uint64]sighting)
+	work := 0
+
+	current := pack(initial)
+	var next PackedGeneration
+	var cycle *Cycle
+
+	for gen := 0; gen < generations; gen++ {
+		if cycle == nil {
+			hash := current.patternHash()
+			earlier, ok := seen[hash]
+			if ok && earlier.length == len(current.words) && repeats(initial, table, earlier.gen, current) {
+				cycle = &Cycle{Start: earlier.gen, Period: gen - earlier.gen, Drift: current.Offset - earlier.offset}
+				skipped := (generations - gen) / cycle.Period
+				current.Offset += skipped * cycle.Drift
+				gen += skipped * cycle.Period
+				if gen == generations {
+					break
+				}
+			} else if len(seen) == maxCycleSearch || work > maxSearchWords {
+				return PackedGeneration{}, nil, fmt.Errorf("no pattern repeated within %d generations", gen)
+			} else if !ok {
+				seen[hash] = sighting{gen: gen, offset: current.Offset, length: len(current.words)}
+			}
+			work += len(current.words)
+		}
+
+		current, next = current.Step(table, next), current
+	}
+
+	return current, cycle, nil
+}
+
+// repeats reports whether p has the same pattern as generation gen.
+func repeats(initial Generation, table RuleTable, gen int, p PackedGeneration) bool {
+	earlier := simulatePacked(initial, table, gen)
+	earlier.Offset = p.Offset
+	return earlier.Equal(p)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestAdvance compares advance with plain simulation on random sets of rules
+// and initial states, for a spread of generation counts. Random rules often
+// cycle with periods above 1, unlike the puzzle input.
+func TestAdvance(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		// Bit 0 can't be set, or empty pots would grow plants
+		table := RuleTable(r.Uint32() &^ 1)
+
+		initial := Generation{State: make(State, 1+r.Intn(40))}
+		for p := range initial.State {
+			initial.State[p] = r.Intn(2) == 0
+		}
+
+		for _, generations := range []int{0, 1, 17, 100, 999, 2500} {
+			want := simulatePacked(initial, table, generations)
+			got, cycle, err := advance(initial, table, generations)
+			if err != nil {
+				t.Fatalf("rules %032b from %s: %s", uint32(table), formatState(initial.State), err)
+			}
+			if !got.Equal(want) {
+				t.Fatalf(
+					"rules %032b from %s after %d generations (cycle %+v): got %s from pot %d, want %s from pot %d",
+					uint32(table), formatState(initial.State), generations, cycle,
+					formatState(got.Unpack().State), got.Offset, formatState(want.Unpack().State), want.Offset,
+				)
+			}
+		}
+	}
+}
+
+// TestAdvanceGrowing checks that advance gives up on patterns that grow
+// forever instead of running out of memory.
+func TestAdvanceGrowing(t *testing.T) {
+	// A plant in any of the middle three pots grows one, so a lone plant
+	// becomes a block two pots longer every generation
+	var table RuleTable
+	for n := uint(0); n < 1<<windowSize; n++ {
+		if n&0b01110 != 0 {
+			table |= 1 << n
+		}
+	}
+	initial := Generation{State: State{true}}
+
+	if _, cycle, err := advance(initial, table, 50000000000); err == nil {
+		t.Fatalf("found cycle %+v in a growing pattern", *cycle)
+	}
+}
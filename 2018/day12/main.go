@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -31,18 +32,6 @@ type Rule struct {
 	Output bool
 }
 
-func (r Rule) Matches(window State) bool {
-	if len(window) != len(r.Input) {
-		return false
-	}
-	for i := 0; i < len(r.Input); i++ {
-		if window[i] != r.Input[i] {
-			return false
-		}
-	}
-	return true
-}
-
 func readInput(filename string) (Generation, []Rule, error) {
 	path, err := filepath.Abs(filename)
 	if err != nil {
@@ -124,123 +113,64 @@ func parseRule(raw string) (Rule, error) {
 }
 
 const windowSize = 5
-const paddingSize = windowSize - 1
 
-func simulateGrowth(initial Generation, rules []Rule, generations int) (result Generation) {
-	result = expand(initial)
-	for gen := 0; gen < generations; gen++ {
-		result = simulateGeneration(result, rules)
+// simulateGrowth runs the bit-packed simulation for some generations.
+func simulateGrowth(initial Generation, rules []Rule, generations int) (PackedGeneration, error) {
+	table, err := newRuleTable(rules)
+	if err != nil {
+		return PackedGeneration{}, err
 	}
-	return
+	return simulatePacked(initial, table, generations), nil
 }
 
-func expand(gen Generation) (expanded Generation) {
-	lowestPlant, highestPlant := findLowestAndHighestPlants(gen.State)
-	var headPadding, tailPadding int
-	if lowestPlant != nil && *lowestPlant < paddingSize {
-		headPadding = paddingSize - *lowestPlant
-	}
-	if highestPlant != nil && *highestPlant >= len(gen.State)-paddingSize {
-		tailPadding = *highestPlant + windowSize - len(gen.State)
+// getSumAfter adds up the numbers of the pots with plants after some
+// generations, skipping ahead once the pattern starts repeating.
+func getSumAfter(initial Generation, rules []Rule, generations int) (int, *Cycle, error) {
+	table, err := newRuleTable(rules)
+	if err != nil {
+		return 0, nil, err
 	}
-
-	expanded = Generation{ZeroIndex: gen.ZeroIndex}
-
-	paddingToAdd := headPadding + tailPadding
-	if paddingToAdd > 0 {
-		expanded.State = make(State, len(gen.State)+paddingToAdd)
-		copy(expanded.State[headPadding:], gen.State)
-		expanded.ZeroIndex += headPadding
-	} else {
-		expanded.State = gen.State
+	result, cycle, err := advance(initial, table, generations)
+	if err != nil {
+		return 0, nil, err
 	}
-
-	return
+	return result.Sum(), cycle, nil
 }
 
-func findLowestAndHighestPlants(state State) (lowest, highest *int) {
-	for i := 0; i < len(state); i++ {
-		if state[i] && (lowest == nil || i < *lowest) {
-			idx := i
-			lowest = &idx
-			break
-		}
-	}
-	for i := len(state) - 1; i >= 0; i-- {
-		if state[i] && (highest == nil || i > *highest) {
-			idx := i
-			highest = &idx
-			break
-		}
-	}
-	return
-}
+func main() {
+	filename := flag.String("input", "input.txt", "pot input file")
+	generations := flag.Int("generations", 0, "also simulate this many generations directly")
+	showCycle := flag.Bool("cycle", false, "describe the cycle used to skip ahead")
+	flag.Parse()
 
-func simulateGeneration(g Generation, rules []Rule) Generation {
-	next := Generation{
-		State:     make(State, len(g.State)),
-		ZeroIndex: g.ZeroIndex,
+	initial, rules, err := readInput(*filename)
+	if err != nil {
+		log.Fatalf("Error reading input from %s: %s\n", *filename, err)
 	}
 
-	for i := 0; i+windowSize < len(g.State); i++ {
-		current := i + windowSize/2
-		window := g.State[i : i+windowSize]
-
-		for _, rule := range rules {
-			if rule.Matches(window) {
-				next.State[current] = rule.Output
-				break
-			}
-		}
+	twentyGens, err := simulateGrowth(initial, rules, 20)
+	if err != nil {
+		log.Fatalf("Error simulating growth: %s\n", err)
 	}
+	fmt.Printf("Sum of numbers of all pots with plants after 20 generations: %d\n", twentyGens.Sum())
 
-	return expand(next)
-}
-
-func getSumAfter(initial Generation, rules []Rule, generations int) int {
-	result := expand(initial)
-
-	var lastEvaluatedGen, lastSum, lastDelta int
-
-	for gen := 0; gen < generations; gen++ {
-		result = simulateGeneration(result, rules)
-		lastEvaluatedGen = gen
-
-		sum := result.Sum()
-		delta := sum - lastSum
-		lastSum = sum
-
-		if delta != lastDelta {
-			lastDelta = delta
-			continue
-		}
-
-		break
+	fiftyBillionSum, cycle, err := getSumAfter(initial, rules, 50000000000)
+	if err != nil {
+		log.Fatalf("Error simulating growth: %s\n", err)
 	}
-
-	// If we got all the way to the end, just return the result we computed.
-	if lastEvaluatedGen == generations-1 {
-		return lastSum
+	fmt.Printf("Sum of numbers of all pots with plants after 50,000,000,000 generations: %d\n", fiftyBillionSum)
+	if *showCycle && cycle != nil {
+		fmt.Printf(
+			"From generation %d the pattern repeats every %d generation(s), moving %d pot(s) each time\n",
+			cycle.Start, cycle.Period, cycle.Drift,
+		)
 	}
 
-	// Otherwise, we bailed early because the delta between generations
-	// stabilized. We can skip simulating the remaining generations and
-	// just do the math.
-	remainingGens := generations - lastEvaluatedGen - 1
-	return lastSum + remainingGens*lastDelta
-}
-
-func main() {
-	filename := "input.txt"
-
-	initial, rules, err := readInput(filename)
-	if err != nil {
-		log.Fatalf("Error reading input from %s: %s\n", filename, err)
+	if *generations > 0 {
+		result, err := simulateGrowth(initial, rules, *generations)
+		if err != nil {
+			log.Fatalf("Error simulating growth: %s\n", err)
+		}
+		fmt.Printf("Sum of numbers of all pots with plants after %d generations: %d\n", *generations, result.Sum())
 	}
-
-	twentyGens := simulateGrowth(initial, rules, 20)
-	fmt.Printf("Sum of numbers of all pots with plants after 20 generations: %d\n", twentyGens.Sum())
-
-	fiftyBillionSum := getSumAfter(initial, rules, 50000000000)
-	fmt.Printf("Sum of numbers of all pots with plants after 50,000,000,000 generations: %d\n", fiftyBillionSum)
 }
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// RuleTable is the rules as a 32-entry lookup table: bit n is set if a pot
+// whose neighborhood spells n in binary gets a plant, reading the pots left to
+// right from the most significant bit. So ##.#. is 0b11010.
+type RuleTable uint32
+
+// neighborhood returns the table index of a window of five pots.
+func neighborhood(window State) uint {
+	var n uint
+	for _, hasPlant := range window {
+		n <<= 1
+		if hasPlant {
+			n |= 1
+		}
+	}
+	return n
+}
+
+// newRuleTable builds the lookup table from the rules. Neighborhoods without
+// a rule produce no plant, like in the puzzle's example, but two rules
+// disagreeing about one is an error. So is a rule growing a plant among empty
+// pots, which would fill infinitely many of them.
+func newRuleTable(rules []Rule) (RuleTable, error) {
+	var table, seen RuleTable
+	for _, rule := range rules {
+		if len(rule.Input) != windowSize {
+			return 0, fmt.Errorf("rule input has %d pots, not %d", len(rule.Input), windowSize)
+		}
+		n := neighborhood(rule.Input)
+		bit := RuleTable(1) << n
+		if seen&bit != 0 && (table&bit != 0) != rule.Output {
+			return 0, fmt.Errorf("rules disagree about %s", formatState(rule.Input))
+		}
+		seen |= bit
+		if rule.Output {
+			table |= bit
+		}
+	}
+	if table&1 != 0 {
+		return 0, fmt.Errorf("rule %s => # would grow plants in infinitely many empty pots", strings.Repeat(".", windowSize))
+	}
+	return table, nil
+}
+
+func formatState(state State) string {
+	var b strings.Builder
+	for _, hasPlant := range state {
+		if hasPlant {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+// PackedGeneration is a generation stored 64 pots to a word. Bit i of the
+// state, counting from the least significant bit of the first word, is pot
+// Offset+i. It's kept trimmed: pot Offset has a plant, and the last word
+// isn't empty, unless there are no plants at all.
+type PackedGeneration struct {
+	words  []uint64
+	Offset int
+}
+
+func pack(g Generation) PackedGeneration {
+	p := PackedGeneration{
+		words:  make([]uint64, (len(g.State)+63)/64),
+		Offset: -g.ZeroIndex,
+	}
+	for i, hasPlant := range g.State {
+		if hasPlant {
+			p.words[i/64] |= 1 << uint(i%64)
+		}
+	}
+	p.trim()
+	return p
+}
+
+// Unpack turns p back into a Generation starting at its first plant.
+func (p PackedGeneration) Unpack() Generation {
+	g := Generation{State: make(State, p.Len()), ZeroIndex: -p.Offset}
+	for i := range g.State {
+		g.State[i] = p.words[i/64]&(1<<uint(i%64)) != 0
+	}
+	return g
+}
+
+// Len is the number of pots from the first plant to the last.
+func (p PackedGeneration) Len() int {
+	if len(p.words) == 0 {
+		return 0
+	}
+	return 64*(len(p.words)-1) + bits.Len64(p.words[len(p.words)-1])
+}
+
+// Sum adds up the numbers of the pots with plants.
+func (p PackedGeneration) Sum() (sum int) {
+	for i, w := range p.words {
+		for w != 0 {
+			sum += p.Offset + 64*i + bits.TrailingZeros64(w)
+			w &= w - 1
+		}
+	}
+	return
+}
+
+// Equal reports whether p and q have plants in the same pots.
+func (p PackedGeneration) Equal(q PackedGeneration) bool {
+	if len(p.words) == 0 && len(q.words) == 0 {
+		// Without plants, the offset doesn't mean anything
+		return true
+	}
+	if p.Offset != q.Offset || len(p.words) != len(q.words) {
+		return false
+	}
+	for i := range p.words {
+		if p.words[i] != q.words[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// trim shifts the state so its first plant is bit 0, and drops empty words
+// from the end.
+func (p *PackedGeneration) trim() {
+	first := 0
+	for first < len(p.words) && p.words[first] == 0 {
+		first++
+	}
+	if first == len(p.words) {
+		p.words = p.words[:0]
+		return
+	}
+
+	shift := uint(bits.TrailingZeros64(p.words[first]))
+	p.Offset += 64*first + int(shift)
+
+	n := len(p.words) - first
+	for i := 0; i < n; i++ {
+		w := p.words[first+i] >> shift
+		if shift > 0 && first+i+1 < len(p.words) {
+			w |= p.words[first+i+1] << (64 - shift)
+		}
+		p.words[i] = w
+	}
+	p.words = p.words[:n]
+
+	for len(p.words) > 0 && p.words[len(p.words)-1] == 0 {
+		p.words = p.words[:len(p.words)-1]
+	}
+}
+
+// Step computes the next generation into dst, reusing its storage, and
+// returns it.
+//
+// Every pot's neighborhood indexes the lookup table at once, 64 pots at a
+// time: each of the five neighbors gets a word of its bits, and the table is
+// evaluated as a tree of multiplexers selecting on one neighbor per level,
+// starting from the table's 32 entries as all-zero or all-one words.
+func (p PackedGeneration) Step(table RuleTable, dst PackedGeneration) PackedGeneration {
+	// Plants can spread up to two pots past either end, so the next
+	// generation starts two pots earlier and runs up to four pots longer
+	reach := windowSize / 2
+	n := (p.Len() + 2*reach + 63) / 64
+	if cap(dst.words) < n {
+		dst.words = make([]uint64, n)
+	}
+	dst.words = dst.words[:n]
+	dst.Offset = p.Offset - reach
+
+	var leaves [1 << windowSize]uint64
+	for i := range leaves {
+		if table&(1<<uint(i)) != 0 {
+			leaves[i] = ^uint64(0)
+		}
+	}
+
+	// Output bit j is pot p.Offset+j-2, whose neighbors are input bits j-4
+	// (leftmost) through j (rightmost)
+	word := func(i int) uint64 {
+		if i >= 0 && i < len(p.words) {
+			return p.words[i]
+		}
+		return 0
+	}
+	for i := range dst.words {
+		current, previous := word(i), word(i-1)
+		var neighbors [windowSize]uint64
+		for k := range neighbors {
+			// neighbors[k] holds the pot k places left of the rightmost,
+			// which is index bit k
+			neighbors[k] = current<<uint(k) | previous>>uint(64-k)
+		}
+
+		level := leaves
+		for k, size := 0, len(level); size > 1; k, size = k+1, size/2 {
+			s := neighbors[k]
+			for j := 0; j < size/2; j++ {
+				level[j] = level[2*j]&^s | level[2*j+1]&s
+			}
+		}
+		dst.words[i] = level[0]
+	}
+
+	dst.trim()
+	return dst
+}
+
+// simulatePacked runs generations of the bit-packed simulation.
+func simulatePacked(initial Generation, table RuleTable, generations int) PackedGeneration {
+	current := pack(initial)
+	var next PackedGeneration
+	for gen := 0; gen < generations; gen++ {
+		current, next = current.Step(table, next), current
+	}
+	return current
+}
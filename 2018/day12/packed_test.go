@@ -0,0 +1,134 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+const paddingSize = windowSize - 1
+
+func (r Rule) Matches(window State) bool {
+	if len(window) != len(r.Input) {
+		return false
+	}
+	for i := 0; i < len(r.Input); i++ {
+		if window[i] != r.Input[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func expand(gen Generation) (expanded Generation) {
+	lowestPlant, highestPlant := findLowestAndHighestPlants(gen.State)
+	var headPadding, tailPadding int
+	if lowestPlant != nil && *lowestPlant < paddingSize {
+		headPadding = paddingSize - *lowestPlant
+	}
+	if highestPlant != nil && *highestPlant >= len(gen.State)-paddingSize {
+		tailPadding = *highestPlant + windowSize - len(gen.State)
+	}
+
+	expanded = Generation{ZeroIndex: gen.ZeroIndex}
+
+	paddingToAdd := headPadding + tailPadding
+	if paddingToAdd > 0 {
+		expanded.State = make(State, len(gen.State)+paddingToAdd)
+		copy(expanded.State[headPadding:], gen.State)
+		expanded.ZeroIndex += headPadding
+	} else {
+		expanded.State = gen.State
+	}
+
+	return
+}
+
+func findLowestAndHighestPlants(state State) (lowest, highest *int) {
+	for i := 0; i < len(state); i++ {
+		if state[i] && (lowest == nil || i < *lowest) {
+			idx := i
+			lowest = &idx
+			break
+		}
+	}
+	for i := len(state) - 1; i >= 0; i-- {
+		if state[i] && (highest == nil || i > *highest) {
+			idx := i
+			highest = &idx
+			break
+		}
+	}
+	return
+}
+
+// simulateGeneration is the original simulation, kept to check the
+// bit-packed one against. It matches every window of pots against every rule
+// and pads the result so plants always have room to spread.
+func simulateGeneration(g Generation, rules []Rule) Generation {
+	next := Generation{
+		State:     make(State, len(g.State)),
+		ZeroIndex: g.ZeroIndex,
+	}
+
+	for i := 0; i+windowSize <= len(g.State); i++ {
+		current := i + windowSize/2
+		window := g.State[i : i+windowSize]
+
+		for _, rule := range rules {
+			if rule.Matches(window) {
+				next.State[current] = rule.Output
+				break
+			}
+		}
+	}
+
+	return expand(next)
+}
+
+// TestStep compares the bit-packed simulation with the original one, which
+// matches every pot against every rule, after each of the first generations
+// of random sets of rules and initial states.
+func TestStep(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		rules := make([]Rule, 0, 1<<windowSize)
+		// The empty neighborhood can't grow a plant, or empty pots would
+		// fill up
+		for n := 1; n < 1<<windowSize; n++ {
+			rule := Rule{Input: make(State, windowSize), Output: r.Intn(2) == 0}
+			for k := range rule.Input {
+				rule.Input[k] = n&(1<<uint(windowSize-1-k)) != 0
+			}
+			rules = append(rules, rule)
+		}
+		table, err := newRuleTable(rules)
+		if err != nil {
+			t.Fatalf("building rule table: %s", err)
+		}
+
+		initial := Generation{State: make(State, 1+r.Intn(40)), ZeroIndex: r.Intn(10)}
+		for p := range initial.State {
+			initial.State[p] = r.Intn(2) == 0
+		}
+
+		packed := pack(initial)
+		var next PackedGeneration
+		slices := expand(initial)
+		for gen := 1; gen <= 200; gen++ {
+			packed, next = packed.Step(table, next), packed
+			slices = simulateGeneration(slices, rules)
+			if want := pack(slices); !packed.Equal(want) {
+				t.Fatalf(
+					"rules %032b from %s, generation %d: packed simulation has %s from pot %d, want %s from pot %d",
+					uint32(table), formatState(initial.State), gen,
+					formatState(packed.Unpack().State), packed.Offset, formatState(want.Unpack().State), want.Offset,
+				)
+			}
+			if packed.Sum() != slices.Sum() {
+				t.Fatalf("rules %032b from %s, generation %d: sum is %d, want %d",
+					uint32(table), formatState(initial.State), gen, packed.Sum(), slices.Sum())
+			}
+		}
+	}
+}